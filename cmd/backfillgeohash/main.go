@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/shikang/travote-be/geo"
+	"github.com/shikang/travote-be/structs"
+)
+
+// BackfillGeohash - Set geohash and geohash4 on every place from its lat/long. Returns places scanned and updated.
+func BackfillGeohash(db *dynamodb.DynamoDB, dryRun bool) (int, int, error) {
+	proj := expression.NamesList(expression.Name("abbr"), expression.Name("id"), expression.Name("lat"), expression.Name("long"), expression.Name("geohash"))
+	expr, err := expression.NewBuilder().WithProjection(proj).Build()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Build the scan input parameters
+	params := &dynamodb.ScanInput{
+		ExpressionAttributeNames: expr.Names(),
+		ProjectionExpression:     expr.Projection(),
+		TableName:                aws.String("Places"),
+	}
+
+	scanned, updated := 0, 0
+	var updateErr error
+	err = db.ScanPages(params, func(page *dynamodb.ScanOutput, lastPage bool) bool {
+		places := []structs.Place{}
+		updateErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &places)
+		if updateErr != nil {
+			return false
+		}
+
+		for _, place := range places {
+			scanned++
+
+			geohash := geo.EncodeGeohash(place.Lat, place.Long, geo.GeohashPrecision)
+			if place.Geohash == geohash {
+				continue
+			}
+
+			fmt.Println("Place " + place.Abbr + " | " + place.ID + ": " + place.Geohash + " -> " + geohash)
+			updated++
+			if dryRun {
+				continue
+			}
+
+			updateErr = UpdatePlaceGeohash(db, place, geohash)
+			if updateErr != nil {
+				return false
+			}
+		}
+
+		return true
+	})
+	if err != nil {
+		return scanned, updated, err
+	}
+
+	return scanned, updated, updateErr
+}
+
+// UpdatePlaceGeohash - Set the place's geohash and the geohash index partition key
+func UpdatePlaceGeohash(db *dynamodb.DynamoDB, place structs.Place, geohash string) error {
+	update := expression.Set(expression.Name("geohash"), expression.Value(geohash)).
+		Set(expression.Name("geohash4"), expression.Value(geohash[:geo.GeohashIndexPrecision]))
+	cond := expression.AttributeExists(expression.Name("id"))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+
+	// Build the update input parameters
+	params := &dynamodb.UpdateItemInput{
+		TableName: aws.String("Places"),
+		Key: map[string]*dynamodb.AttributeValue{
+			"abbr": {S: aws.String(place.Abbr)},
+			"id":   {S: aws.String(place.ID)},
+		},
+		ConditionExpression:       expr.Condition(),
+		UpdateExpression:          expr.Update(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+
+	// Make the DynamoDB UpdateItem API call
+	_, err = db.UpdateItem(params)
+	return err
+}
+
+func main() {
+	region := flag.String("region", "ap-southeast-1", "AWS region of the Places table")
+	dryRun := flag.Bool("dry-run", false, "Print the changes without updating any place")
+	flag.Parse()
+
+	db := dynamodb.New(session.New(), aws.NewConfig().WithRegion(*region))
+	scanned, updated, err := BackfillGeohash(db, *dryRun)
+	fmt.Printf("Scanned %d places, updated %d\n", scanned, updated)
+	if err != nil {
+		fmt.Println("Backfill failed: " + err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"archive/zip"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Lambdas - Every cmd/lambda* folder, the lambdas that are deployed
+func Lambdas() ([]string, error) {
+	dirs, err := filepath.Glob(filepath.Join("cmd", "lambda*"))
+	if err != nil {
+		return nil, err
+	}
+
+	lambdas := []string{}
+	for _, dir := range dirs {
+		lambdas = append(lambdas, filepath.Base(dir))
+	}
+	return lambdas, nil
+}
+
+// BuildLambda - Cross-compile cmd/<lambda> for the Lambda runtime into the executable binary in dir
+func BuildLambda(lambda string, arch string, dir string, binary string) (string, error) {
+	output := filepath.Join(dir, binary)
+
+	cmd := exec.Command("go", "build", "-trimpath", "-o", output, "./"+filepath.ToSlash(filepath.Join("cmd", lambda)))
+	cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH="+arch, "CGO_ENABLED=0")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return output, cmd.Run()
+}
+
+// ZipLambda - Zip the binary as an executable, which Lambda requires even when the zip is made on Windows
+func ZipLambda(binary string, zipPath string) error {
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer zipFile.Close()
+
+	writer := zip.NewWriter(zipFile)
+
+	header := &zip.FileHeader{
+		Name:     filepath.Base(binary),
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	}
+	header.SetMode(0755)
+
+	entry, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(binary)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(entry, file)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+func main() {
+	out := flag.String("out", "out", "folder the <lambda>.zip files are written to")
+	arch := flag.String("arch", "amd64", "architecture of the Lambda functions, amd64 or arm64")
+	binary := flag.String("binary", "main", "name of the executable in the zip, the handler of the go1.x runtime or bootstrap for provided.al2")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "go run ./cmd/build [flags] [lambda ...]")
+		fmt.Fprintln(flag.CommandLine.Output(), "Builds every cmd/lambda* if no lambda is given.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	lambdas := flag.Args()
+	if len(lambdas) == 0 {
+		var err error
+		lambdas, err = Lambdas()
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	err := os.MkdirAll(*out, 0755)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	for _, lambda := range lambdas {
+		lambda = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(lambda)), "cmd/")
+
+		dir, err := ioutil.TempDir("", "travote-build-")
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Println("Building " + lambda + " ...")
+		binaryPath, err := BuildLambda(lambda, *arch, dir, *binary)
+		if err == nil {
+			zipPath := filepath.Join(*out, lambda+".zip")
+			err = ZipLambda(binaryPath, zipPath)
+			if err == nil {
+				fmt.Println("Output: " + zipPath)
+			}
+		}
+
+		os.RemoveAll(dir)
+		if err != nil {
+			fmt.Println("Building " + lambda + " failed: " + err.Error())
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+
+	"github.com/shikang/travote-be/logging"
+)
+
+// rawLambdaErrors - Serve the lambda's own response even when it also returns an error, set by -raw-errors
+var rawLambdaErrors = false
+
+// newRequestID - Random ID standing in for API Gateway's request ID
+func newRequestID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// PathParameters - Values of the {name} segments of resource in path, false if path is not of resource
+func PathParameters(resource string, path string) (map[string]string, bool) {
+	resourceSegments := strings.Split(strings.Trim(resource, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(resourceSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, segment := range resourceSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			value, err := url.PathUnescape(pathSegments[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			params[strings.Trim(segment, "{}")] = value
+		} else if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// ProxyRequest - API Gateway proxy event for an HTTP request to resource, such as /places/{abbr}/{id}
+func ProxyRequest(r *http.Request, resource string) (events.APIGatewayProxyRequest, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	// API Gateway passes null rather than an empty map for resources without parameters
+	var pathParameters map[string]string
+	if strings.Contains(resource, "{") {
+		pathParameters, _ = PathParameters(resource, r.URL.Path)
+	}
+
+	request := events.APIGatewayProxyRequest{
+		Resource:                        resource,
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         map[string]string{},
+		MultiValueHeaders:               map[string][]string{},
+		QueryStringParameters:           map[string]string{},
+		MultiValueQueryStringParameters: map[string][]string{},
+		PathParameters:                  pathParameters,
+		Body:                            string(body),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID:  newRequestID(),
+			Stage:      "dev",
+			HTTPMethod: r.Method,
+			Identity:   events.APIGatewayRequestIdentity{SourceIP: r.RemoteAddr},
+		},
+	}
+
+	// API Gateway passes the last value of repeated headers and parameters in the single value maps
+	for name, values := range r.Header {
+		request.Headers[name] = values[len(values)-1]
+		request.MultiValueHeaders[name] = values
+	}
+	for name, values := range r.URL.Query() {
+		request.QueryStringParameters[name] = values[len(values)-1]
+		request.MultiValueQueryStringParameters[name] = values
+	}
+
+	return request, nil
+}
+
+// corsPreflight - CORS preflight response, which API Gateway answers itself without invoking the lambda
+func corsPreflight(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type,Authorization,X-Identity-Provider,X-Identity-User-Id,X-Identity-Token")
+	w.Header().Set("Access-Control-Allow-Methods", "OPTIONS,GET,POST,PUT,DELETE")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdaptLambda - http.Handler invoking handler the way API Gateway's lambda proxy integration does.
+// Requests are logged by handler, wrap it with logging.Handler as the lambdas' mains do.
+func AdaptLambda(resource string, handler logging.LambdaHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := PathParameters(resource, r.URL.Path); !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+			return
+		}
+
+		if r.Method == http.MethodOptions {
+			corsPreflight(w)
+			return
+		}
+
+		request, err := ProxyRequest(r, resource)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := lambdacontext.NewContext(r.Context(), &lambdacontext.LambdaContext{AwsRequestID: newRequestID()})
+		response, err := handler(ctx, request)
+		if err != nil && !rawLambdaErrors {
+			// A lambda returning an error fails the invocation, API Gateway then discards the response
+			logging.Default().Error("Lambda error, response replaced by 502", logging.Fields{
+				"request_id": request.RequestContext.RequestID,
+				"method":     r.Method,
+				"path":       r.URL.Path,
+				"status":     response.StatusCode,
+				"error":      err,
+			})
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"message": "Internal server error"}`))
+			return
+		}
+
+		for name, value := range response.Headers {
+			w.Header().Set(name, value)
+		}
+		for name, values := range response.MultiValueHeaders {
+			for _, value := range values {
+				w.Header().Add(name, value)
+			}
+		}
+
+		statusCode := response.StatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		w.WriteHeader(statusCode)
+		w.Write([]byte(response.Body))
+	}
+}
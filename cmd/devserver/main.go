@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/shikang/travote-be/geo"
+	"github.com/shikang/travote-be/handlers"
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/structs"
+)
+
+// DevSeed - Caps for field names, because of json.Marshal requirements
+type DevSeed struct {
+	Countries []structs.Country `json:"countries"`
+	Places    []structs.Place   `json:"places"`
+}
+
+// LoadDevSeed - Put the countries and places of the JSON file at path into memory
+func LoadDevSeed(memory *store.MemoryStore, path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	seed := DevSeed{}
+	err = json.Unmarshal(data, &seed)
+	if err != nil {
+		return err
+	}
+
+	for _, country := range seed.Countries {
+		memory.PutCountry(country)
+	}
+	for _, place := range seed.Places {
+		if place.Geohash == "" {
+			place.Geohash = geo.EncodeGeohash(place.Lat, place.Long, geo.GeohashPrecision)
+		}
+		memory.PutPlace(place)
+	}
+
+	fmt.Printf("Seeded %d countries and %d places from %s\n", len(seed.Countries), len(seed.Places), path)
+	return nil
+}
+
+// NewDevServeMux - Routes of the API Gateway stage, each to its lambda's handler
+func NewDevServeMux(memory *store.MemoryStore) *http.ServeMux {
+	verifier := StubTokenVerifier{}
+	identities := identity.IdentityVerifiers{
+		identity.ProviderFacebook: verifier,
+		identity.ProviderGoogle:   verifier,
+		identity.ProviderApple:    verifier,
+	}
+	sessions := NewDevSessionSigner()
+
+	countries := handlers.CountriesHandler{Countries: memory}
+	places := handlers.PlacesHandler{Places: memory}
+	votes := handlers.VotesHandler{Votes: memory, Places: memory, Identities: identities, Sessions: sessions}
+	login := handlers.LoginHandler{Identities: identities, Sessions: sessions}
+
+	mux := http.NewServeMux()
+	mux.Handle("/countries", AdaptLambda("/countries", logging.Handler("lambdagetcountries", countries.HandleGetCountriesRequest)))
+	mux.Handle("/places", AdaptLambda("/places", logging.Handler("lambdagetplaces", places.HandleGetPlacesRequest)))
+	mux.Handle("/places/", AdaptLambda("/places/{abbr}/{id}", logging.Handler("lambdagetplaces", places.HandleGetPlacesRequest)))
+	mux.Handle("/vote", AdaptLambda("/vote", logging.Handler("lambdavoteplace", votes.HandleVotePlaceRequest)))
+	mux.Handle("/login", AdaptLambda("/login", logging.Handler("lambdalogin", login.HandleLoginRequest)))
+	return mux
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	seed := flag.String("seed", "", "JSON file of countries and places to start with")
+	flag.BoolVar(&rawLambdaErrors, "raw-errors", false, "serve the lambda's response even when it also returns an error, instead of API Gateway's 502")
+	flag.Parse()
+
+	memory := store.NewMemoryStore()
+	if *seed != "" {
+		err := LoadDevSeed(memory, *seed)
+		if err != nil {
+			fmt.Println("Loading seed failed: " + err.Error())
+			os.Exit(1)
+		}
+	}
+
+	fmt.Println("Serving /countries, /places, /vote and /login on http://" + *addr)
+	err := http.ListenAndServe(*addr, NewDevServeMux(memory))
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,14 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/shikang/travote-be/handlers"
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/store"
+)
+
+func main() {
+	handler := handlers.PlacesHandler{Places: store.NewDynamoPlaceStore(store.NewDynamoDB())}
+	lambda.Start(logging.Handler("lambdagetplaces", handler.HandleGetPlacesRequest))
+}
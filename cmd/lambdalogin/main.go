@@ -0,0 +1,18 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/shikang/travote-be/handlers"
+	"github.com/shikang/travote-be/identity/providers"
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/session"
+)
+
+func main() {
+	handler := handlers.LoginHandler{
+		Identities: providers.NewIdentityVerifiers(),
+		Sessions:   session.NewSessionSigner(),
+	}
+	lambda.Start(logging.Handler("lambdalogin", handler.HandleLoginRequest))
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+
+	"github.com/shikang/travote-be/handlers"
+	"github.com/shikang/travote-be/identity/providers"
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/session"
+	"github.com/shikang/travote-be/store"
+)
+
+func main() {
+	db := store.NewDynamoDB()
+	handler := handlers.VotesHandler{
+		Votes:      store.NewDynamoVoteStore(db),
+		Places:     store.NewDynamoPlaceStore(db),
+		Identities: providers.NewIdentityVerifiers(),
+		Sessions:   session.NewSessionSigner(),
+	}
+	lambda.Start(logging.Handler("lambdavoteplace", handler.HandleVotePlaceRequest))
+}
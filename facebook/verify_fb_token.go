@@ -1,19 +1,30 @@
-package main
+package facebook
 
 // Use this code snippet in your app.
 // If you need more information about configurations or implementing the sample code, visit the AWS docs:
 // https://docs.aws.amazon.com/sdk-for-go/v1/developer-guide/setting-up.html
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
+
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/utils"
 )
 
 // FacebookInfo - Caps for field names, because of json.Marshal requirements
@@ -22,20 +33,33 @@ type FacebookInfo struct {
 	AppSecret string `json:"travote_fb_app_secret"`
 }
 
-// FacebookAppAccessTokenGraphAPIResponse - Caps for field names, because of json.Marshal requirements
-type FacebookAppAccessTokenGraphAPIResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
+// FacebookGraphAPIError - Caps for field names, because of json.Marshal requirements
+type FacebookGraphAPIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    int    `json:"code"`
+	Subcode int    `json:"error_subcode"`
 }
 
-// FacebookCheckAccessTokenGraphAPIResponse - Caps for field names, because of json.Marshal requirements
+// FacebookDebugAccessTokenGraphAPIResponse - Caps for field names, because of json.Marshal requirements
 type FacebookDebugAccessTokenGraphAPIResponse struct {
 	Data struct {
-		IsValid bool   `json:"is_valid"`
-		UserID  string `json:"user_id"`
+		AppID     string                 `json:"app_id"`
+		IsValid   bool                   `json:"is_valid"`
+		UserID    string                 `json:"user_id"`
+		ExpiresAt int64                  `json:"expires_at"`
+		Scopes    []string               `json:"scopes"`
+		Error     *FacebookGraphAPIError `json:"error"`
 	} `json:"data"`
+	Error *FacebookGraphAPIError `json:"error"`
 }
 
+// Graph API error code for an expired or invalidated access token
+const facebookErrCodeAccessToken = 190
+
+// Graph API error subcode for an expired access token
+const facebookErrSubcodeExpired = 463
+
 func GetFacebookInfoFromAWS() (FacebookInfo, error) {
 	secretName := "TravoteFacebookAppInfo"
 	//region := "ap-southeast-1"
@@ -52,33 +76,12 @@ func GetFacebookInfoFromAWS() (FacebookInfo, error) {
 
 	result, err := svc.GetSecretValue(input)
 	if err != nil {
+		// Cast err to awserr.Error to get the Code, e.g. secretsmanager.ErrCodeResourceNotFoundException
+		fields := logging.Fields{"component": "facebook", "secret": secretName, "error": err}
 		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
-			case secretsmanager.ErrCodeDecryptionFailure:
-				// Secrets Manager can't decrypt the protected secret text using the provided KMS key.
-				fmt.Println(secretsmanager.ErrCodeDecryptionFailure, aerr.Error())
-
-			case secretsmanager.ErrCodeInternalServiceError:
-				// An error occurred on the server side.
-				fmt.Println(secretsmanager.ErrCodeInternalServiceError, aerr.Error())
-
-			case secretsmanager.ErrCodeInvalidParameterException:
-				// You provided an invalid value for a parameter.
-				fmt.Println(secretsmanager.ErrCodeInvalidParameterException, aerr.Error())
-
-			case secretsmanager.ErrCodeInvalidRequestException:
-				// You provided a parameter value that is not valid for the current state of the resource.
-				fmt.Println(secretsmanager.ErrCodeInvalidRequestException, aerr.Error())
-
-			case secretsmanager.ErrCodeResourceNotFoundException:
-				// We can't find the resource that you asked for.
-				fmt.Println(secretsmanager.ErrCodeResourceNotFoundException, aerr.Error())
-			}
-		} else {
-			// Print the error, cast err to awserr.Error to get the Code and
-			// Message from an error.
-			fmt.Println(err.Error())
+			fields["aws_error_code"] = aerr.Code()
 		}
+		logging.Default().Error("Getting secret failed", fields)
 		return FacebookInfo{}, err
 	}
 
@@ -89,7 +92,7 @@ func GetFacebookInfoFromAWS() (FacebookInfo, error) {
 		secretString = *result.SecretString
 	} else {
 		err := errors.New("Expected Secret to be in string")
-		fmt.Println("Expected Secret to be in string")
+		logging.Default().Error("Getting secret failed", logging.Fields{"component": "facebook", "secret": secretName, "error": err})
 
 		return FacebookInfo{}, err
 	}
@@ -98,56 +101,233 @@ func GetFacebookInfoFromAWS() (FacebookInfo, error) {
 	unmarshalErr := json.Unmarshal([]byte(secretString), &fbInfo)
 
 	if unmarshalErr != nil {
-		fmt.Println("Encountered error while unmarshalling info: " + unmarshalErr.Error())
+		logging.Default().Error("Unmarshalling secret failed", logging.Fields{"component": "facebook", "secret": secretName, "error": unmarshalErr})
 	}
 
 	return fbInfo, unmarshalErr
 }
 
-func VerifyFacebookAccessToken(userID string, accessToken string) bool {
-	// Get App ID and App Secret from AWS secret manager
-	fbInfo, err := GetFacebookInfoFromAWS()
-	if err != nil {
-		return false
+// Verified tokens without an expiry are re-checked after this long
+const maxUserTokenCacheDuration = time.Hour
+
+// Verified tokens cached before expired ones are pruned
+const maxUserTokenCacheSize = 1000
+
+type cachedUserToken struct {
+	UserID    string
+	ExpiresAt time.Time
+}
+
+var _ identity.TokenVerifier = (*FacebookTokenVerifier)(nil)
+
+// FacebookTokenVerifier - TokenVerifier for Facebook user access tokens.
+// The app secret and verified user tokens are kept across warm invocations.
+type FacebookTokenVerifier struct {
+	// GetFacebookInfo - Source of the app ID and secret, GetFacebookInfoFromAWS by default
+	GetFacebookInfo func() (FacebookInfo, error)
+
+	// RequiredScopes - Permissions every user token must have been granted
+	RequiredScopes []string
+
+	// Client - HTTP client for Graph API calls
+	Client *http.Client
+
+	mutex      sync.Mutex
+	fbInfo     *FacebookInfo
+	userTokens map[string]cachedUserToken
+}
+
+// Graph API calls taking longer than this fail, override with FACEBOOK_GRAPH_TIMEOUT e.g. "3s"
+const defaultFacebookGraphTimeout = 5 * time.Second
+
+// NewFacebookTokenVerifier - TokenVerifier for Facebook user access tokens.
+// Tokens must have been granted the comma separated permissions of FACEBOOK_REQUIRED_SCOPES, if set.
+func NewFacebookTokenVerifier() *FacebookTokenVerifier {
+	timeout, err := time.ParseDuration(os.Getenv("FACEBOOK_GRAPH_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		timeout = defaultFacebookGraphTimeout
 	}
 
-	// Get App Access Token
-	url := "https://graph.facebook.com/oauth/access_token?client_id=" + fbInfo.AppID + "&client_secret=" + fbInfo.AppSecret + "&grant_type=client_credentials"
-	//fmt.Println("Getting Facebook App Access Token - " + url)
-	appAccessTokenResp, err := http.Get(url)
-	if err != nil {
-		fmt.Println("Facebook Get App Access Token Graph API fail! - " + err.Error())
-		return false
+	requiredScopes := []string{}
+	for _, scope := range strings.Split(os.Getenv("FACEBOOK_REQUIRED_SCOPES"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			requiredScopes = append(requiredScopes, scope)
+		}
+	}
+
+	return &FacebookTokenVerifier{
+		GetFacebookInfo: GetFacebookInfoFromAWS,
+		RequiredScopes:  requiredScopes,
+		Client:          &http.Client{Timeout: timeout},
+		userTokens:      map[string]cachedUserToken{},
+	}
+}
+
+// userTokenKey - Cache key for an access token, so raw tokens are not kept in memory
+func userTokenKey(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return hex.EncodeToString(hash[:])
+}
+
+// getFacebookInfo - App ID and secret, fetched on first use
+func (verifier *FacebookTokenVerifier) getFacebookInfo() (FacebookInfo, error) {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	if verifier.fbInfo == nil {
+		fbInfo, err := verifier.GetFacebookInfo()
+		if err != nil {
+			return FacebookInfo{}, err
+		}
+		verifier.fbInfo = &fbInfo
+	}
+
+	return *verifier.fbInfo, nil
+}
+
+// resetFacebookInfo - Forget the app secret, e.g. after Facebook rejected it because it was rotated
+func (verifier *FacebookTokenVerifier) resetFacebookInfo() {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+	verifier.fbInfo = nil
+}
+
+// AppAccessToken - App access token in the app-id|app-secret form, which needs no oauth/access_token call
+func (fbInfo FacebookInfo) AppAccessToken() string {
+	return fbInfo.AppID + "|" + fbInfo.AppSecret
+}
+
+// AppSecretProof - appsecret_proof for Graph API calls made with accessToken
+func (fbInfo FacebookInfo) AppSecretProof(accessToken string) string {
+	mac := hmac.New(sha256.New, []byte(fbInfo.AppSecret))
+	mac.Write([]byte(accessToken))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// getCachedUserToken - User the access token was verified for, if it was verified and has not expired
+func (verifier *FacebookTokenVerifier) getCachedUserToken(key string) (string, bool) {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	cached, ok := verifier.userTokens[key]
+	if !ok {
+		return "", false
+	}
+
+	if time.Now().After(cached.ExpiresAt) {
+		delete(verifier.userTokens, key)
+		return "", false
+	}
+
+	return cached.UserID, true
+}
+
+func (verifier *FacebookTokenVerifier) putCachedUserToken(key string, cached cachedUserToken) {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	if len(verifier.userTokens) >= maxUserTokenCacheSize {
+		now := time.Now()
+		for k, v := range verifier.userTokens {
+			if now.After(v.ExpiresAt) {
+				delete(verifier.userTokens, k)
+			}
+		}
+
+		// Still full of live tokens, start over rather than grow without bound
+		if len(verifier.userTokens) >= maxUserTokenCacheSize {
+			verifier.userTokens = map[string]cachedUserToken{}
+		}
+	}
+
+	verifier.userTokens[key] = cached
+}
+
+// VerifyAccessToken - Check the user access token with the Facebook debug_token Graph API
+func (verifier *FacebookTokenVerifier) VerifyAccessToken(userID string, accessToken string) identity.TokenStatus {
+	key := userTokenKey(accessToken)
+	if cachedUserID, ok := verifier.getCachedUserToken(key); ok {
+		if cachedUserID != userID {
+			return identity.TokenInvalid
+		}
+		return identity.TokenValid
 	}
 
-	appAccessTokenResponse := FacebookAppAccessTokenGraphAPIResponse{}
-	defer appAccessTokenResp.Body.Close()
-	err = json.NewDecoder(appAccessTokenResp.Body).Decode(&appAccessTokenResponse)
+	fbInfo, err := verifier.getFacebookInfo()
 	if err != nil {
-		fmt.Println("Error in decoding Facebook App Access Token Graph API Response - " + err.Error())
-		return false
+		return identity.TokenUpstreamFailure
 	}
 
-	//fmt.Println("Facebook App Access Token = " + appAccessTokenResponse.AccessToken)
+	// Check User Access Token. The app token goes in a header rather than the URL, as it holds the app secret.
+	appAccessToken := fbInfo.AppAccessToken()
+	query := url.Values{}
+	query.Set("input_token", accessToken)
+	query.Set("appsecret_proof", fbInfo.AppSecretProof(appAccessToken))
 
-	// Check User Access Token
-	url = "https://graph.facebook.com/debug_token?input_token=" + accessToken + "&access_token=" + appAccessTokenResponse.AccessToken
-	//fmt.Println("Checking User Access Token - " + url)
-	debugAccessTokenResp, err := http.Get(url)
+	req, err := http.NewRequest("GET", "https://graph.facebook.com/debug_token?"+query.Encode(), nil)
 	if err != nil {
-		fmt.Println("Facebook Debug Access Token Graph API fail! - " + err.Error())
-		return false
+		return identity.TokenUpstreamFailure
+	}
+	req.Header.Set("Authorization", "OAuth "+appAccessToken)
+
+	debugAccessTokenResp, err := verifier.Client.Do(req)
+	if err != nil {
+		// url.Error includes the URL, which holds the user token
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		logging.Default().Error("Facebook Debug Access Token Graph API failed", logging.Fields{"component": "facebook", "error": err})
+		return identity.TokenUpstreamFailure
 	}
 
 	debugAccessTokenResponse := FacebookDebugAccessTokenGraphAPIResponse{}
 	defer debugAccessTokenResp.Body.Close()
 	err = json.NewDecoder(debugAccessTokenResp.Body).Decode(&debugAccessTokenResponse)
 	if err != nil {
-		fmt.Println("Error in decoding Facebook Debug Access Token Graph API Response - " + err.Error())
-		return false
+		logging.Default().Error("Decoding Facebook Debug Access Token Graph API response failed", logging.Fields{"component": "facebook", "error": err})
+		return identity.TokenUpstreamFailure
+	}
+
+	// Top level error is about our request, not the user token
+	if debugAccessTokenResponse.Error != nil {
+		logging.Default().Error("Facebook Debug Access Token Graph API error", logging.Fields{"component": "facebook", "error": debugAccessTokenResponse.Error.Message, "graph_error_code": debugAccessTokenResponse.Error.Code})
+		if debugAccessTokenResponse.Error.Code == facebookErrCodeAccessToken {
+			verifier.resetFacebookInfo()
+		}
+		return identity.TokenUpstreamFailure
+	}
+
+	data := debugAccessTokenResponse.Data
+	if data.Error != nil && data.Error.Subcode == facebookErrSubcodeExpired {
+		return identity.TokenExpired
+	}
+	if data.ExpiresAt > 0 && time.Now().After(time.Unix(data.ExpiresAt, 0)) {
+		return identity.TokenExpired
+	}
+	if !data.IsValid || data.Error != nil {
+		return identity.TokenInvalid
+	}
+	if data.AppID != fbInfo.AppID {
+		return identity.TokenWrongApp
+	}
+	for _, scope := range verifier.RequiredScopes {
+		if !utils.ContainsString(data.Scopes, scope) {
+			return identity.TokenMissingScopes
+		}
 	}
 
-	//fmt.Println("Facebook Debug Access Token Is Valid = " + strconv.FormatBool(debugAccessTokenResponse.Data.IsValid))
-	//fmt.Println("Facebook Debug Access Token User ID = " + debugAccessTokenResponse.Data.UserID)
-	return debugAccessTokenResponse.Data.IsValid && debugAccessTokenResponse.Data.UserID == userID
+	// Cache until Facebook says the token expires, expires_at is 0 for tokens that don't
+	expiresAt := time.Now().Add(maxUserTokenCacheDuration)
+	if data.ExpiresAt > 0 {
+		tokenExpiresAt := time.Unix(data.ExpiresAt, 0)
+		if tokenExpiresAt.Before(expiresAt) {
+			expiresAt = tokenExpiresAt
+		}
+	}
+	verifier.putCachedUserToken(key, cachedUserToken{UserID: data.UserID, ExpiresAt: expiresAt})
+
+	if data.UserID != userID {
+		return identity.TokenInvalid
+	}
+	return identity.TokenValid
 }
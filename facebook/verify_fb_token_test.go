@@ -0,0 +1,317 @@
+package facebook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shikang/travote-be/identity"
+)
+
+var testFacebookInfo = FacebookInfo{AppID: "app-1", AppSecret: "app-secret"}
+
+// graphTransport - Sends Graph API calls to a test server instead of graph.facebook.com
+type graphTransport struct {
+	server *httptest.Server
+}
+
+func (transport graphTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, err := url.Parse(transport.server.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.URL.Scheme = target.Scheme
+	req.URL.Host = target.Host
+	return transport.server.Client().Transport.RoundTrip(req)
+}
+
+// fakeGraph - debug_token of the Graph API, answering each input_token with its response
+type fakeGraph struct {
+	mutex     sync.Mutex
+	responses map[string]string
+	requests  []*http.Request
+
+	// secretFetches - Calls of the verifier's GetFacebookInfo
+	secretFetches int
+}
+
+func (graph *fakeGraph) requestCount() int {
+	graph.mutex.Lock()
+	defer graph.mutex.Unlock()
+	return len(graph.requests)
+}
+
+// newTestFacebookVerifier - FacebookTokenVerifier of testFacebookInfo, calling graph instead of Facebook
+func newTestFacebookVerifier(t *testing.T, responses map[string]string) (*FacebookTokenVerifier, *fakeGraph) {
+	graph := &fakeGraph{responses: responses}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		graph.mutex.Lock()
+		graph.requests = append(graph.requests, r)
+		response, ok := graph.responses[r.URL.Query().Get("input_token")]
+		graph.mutex.Unlock()
+
+		if r.URL.Path != "/debug_token" || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	verifier := NewFacebookTokenVerifier()
+	verifier.Client = &http.Client{Transport: graphTransport{server: server}}
+	verifier.GetFacebookInfo = func() (FacebookInfo, error) {
+		graph.mutex.Lock()
+		defer graph.mutex.Unlock()
+		graph.secretFetches++
+		return testFacebookInfo, nil
+	}
+	return verifier, graph
+}
+
+// debugToken - debug_token response of a valid token of userID, expiring at expiresAt or never if 0
+func debugToken(t *testing.T, appID string, userID string, expiresAt int64, scopes ...string) string {
+	t.Helper()
+	response := FacebookDebugAccessTokenGraphAPIResponse{}
+	response.Data.AppID = appID
+	response.Data.IsValid = true
+	response.Data.UserID = userID
+	response.Data.ExpiresAt = expiresAt
+	response.Data.Scopes = scopes
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Marshal() = %v", err)
+	}
+	return string(body)
+}
+
+func TestFacebookTokenVerifierCache(t *testing.T) {
+	inTenMinutes := time.Now().Add(10 * time.Minute).Unix()
+
+	tests := []struct {
+		name         string
+		expiresAt    int64
+		secondUserID string
+		want         identity.TokenStatus
+		cachedFor    time.Duration
+	}{
+		{"cached until expires_at", inTenMinutes, "1", identity.TokenValid, 10 * time.Minute},
+		{"cached for an hour without expires_at", 0, "1", identity.TokenValid, maxUserTokenCacheDuration},
+		{"cached token of another user", inTenMinutes, "2", identity.TokenInvalid, 10 * time.Minute},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier, graph := newTestFacebookVerifier(t, map[string]string{"token": debugToken(t, testFacebookInfo.AppID, "1", test.expiresAt)})
+
+			if status := verifier.VerifyAccessToken("1", "token"); status != identity.TokenValid {
+				t.Fatalf("VerifyAccessToken() = %v, want valid", status)
+			}
+			if status := verifier.VerifyAccessToken(test.secondUserID, "token"); status != test.want {
+				t.Errorf("VerifyAccessToken() of user %s = %v, want %v", test.secondUserID, status, test.want)
+			}
+			if requests := graph.requestCount(); requests != 1 {
+				t.Errorf("debug_token requests = %d, want 1", requests)
+			}
+
+			cached := verifier.userTokens[userTokenKey("token")]
+			if cachedFor := time.Until(cached.ExpiresAt); cachedFor > test.cachedFor || cachedFor < test.cachedFor-time.Minute {
+				t.Errorf("Cached for %v, want %v", cachedFor, test.cachedFor)
+			}
+		})
+	}
+}
+
+func TestFacebookTokenVerifierCacheExpired(t *testing.T) {
+	verifier, graph := newTestFacebookVerifier(t, map[string]string{"token": debugToken(t, testFacebookInfo.AppID, "1", 0)})
+	verifier.putCachedUserToken(userTokenKey("token"), cachedUserToken{UserID: "2", ExpiresAt: time.Now().Add(-time.Second)})
+
+	if status := verifier.VerifyAccessToken("1", "token"); status != identity.TokenValid {
+		t.Errorf("VerifyAccessToken() = %v, want valid from debug_token", status)
+	}
+	if requests := graph.requestCount(); requests != 1 {
+		t.Errorf("debug_token requests = %d, want 1", requests)
+	}
+}
+
+func TestPutCachedUserTokenSizeCap(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		expired int
+		want    int
+	}{
+		{"below the cap", maxUserTokenCacheSize - 1, 0, maxUserTokenCacheSize},
+		{"expired tokens pruned", maxUserTokenCacheSize, maxUserTokenCacheSize / 2, maxUserTokenCacheSize/2 + 1},
+		{"full of live tokens", maxUserTokenCacheSize, 0, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier := NewFacebookTokenVerifier()
+			for i := 0; i < test.size; i++ {
+				expiresAt := time.Now().Add(time.Hour)
+				if i < test.expired {
+					expiresAt = time.Now().Add(-time.Second)
+				}
+				verifier.userTokens[strconv.Itoa(i)] = cachedUserToken{UserID: "1", ExpiresAt: expiresAt}
+			}
+
+			verifier.putCachedUserToken("new", cachedUserToken{UserID: "1", ExpiresAt: time.Now().Add(time.Hour)})
+			if len(verifier.userTokens) != test.want {
+				t.Errorf("Cached tokens = %d, want %d", len(verifier.userTokens), test.want)
+			}
+			if _, ok := verifier.getCachedUserToken("new"); !ok {
+				t.Errorf("getCachedUserToken() of the new token = false, want it cached")
+			}
+		})
+	}
+}
+
+func TestFacebookTokenVerifierResetsSecret(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          int
+		secretFetches int
+	}{
+		{"rejected app token", facebookErrCodeAccessToken, 2},
+		{"rate limited", 4, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := `{"error":{"message":"Graph error","code":` + strconv.Itoa(test.code) + `}}`
+			verifier, graph := newTestFacebookVerifier(t, map[string]string{"token": response})
+
+			for i := 0; i < 2; i++ {
+				if status := verifier.VerifyAccessToken("1", "token"); status != identity.TokenUpstreamFailure {
+					t.Errorf("VerifyAccessToken() = %v, want upstream failure", status)
+				}
+			}
+			if graph.secretFetches != test.secretFetches {
+				t.Errorf("GetFacebookInfo() calls = %d, want %d", graph.secretFetches, test.secretFetches)
+			}
+		})
+	}
+}
+
+func TestFacebookTokenVerifierStatus(t *testing.T) {
+	inAnHour := time.Now().Add(time.Hour).Unix()
+	anHourAgo := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name     string
+		response string
+		scopes   []string
+		want     identity.TokenStatus
+	}{
+		{"valid", debugToken(t, testFacebookInfo.AppID, "1", inAnHour, "public_profile"), nil, identity.TokenValid},
+		{"required scopes granted", debugToken(t, testFacebookInfo.AppID, "1", inAnHour, "public_profile", "email"), []string{"email"}, identity.TokenValid},
+		{"required scope missing", debugToken(t, testFacebookInfo.AppID, "1", inAnHour, "public_profile"), []string{"email"}, identity.TokenMissingScopes},
+		{"token of another app", debugToken(t, "app-2", "1", inAnHour), nil, identity.TokenWrongApp},
+		{"token of another user", debugToken(t, testFacebookInfo.AppID, "2", inAnHour), nil, identity.TokenInvalid},
+		{"past expires_at", debugToken(t, testFacebookInfo.AppID, "1", anHourAgo), nil, identity.TokenExpired},
+		{"expired subcode", `{"data":{"app_id":"app-1","is_valid":false,"user_id":"1","error":{"message":"Session has expired","code":190,"error_subcode":463}}}`, nil, identity.TokenExpired},
+		{"logged out subcode", `{"data":{"app_id":"app-1","is_valid":false,"user_id":"1","error":{"message":"Session is invalid","code":190,"error_subcode":460}}}`, nil, identity.TokenInvalid},
+		{"not valid", `{"data":{"app_id":"app-1","is_valid":false,"user_id":"1"}}`, nil, identity.TokenInvalid},
+		{"not JSON", `<html>`, nil, identity.TokenUpstreamFailure},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			verifier, _ := newTestFacebookVerifier(t, map[string]string{"token": test.response})
+			verifier.RequiredScopes = test.scopes
+
+			if status := verifier.VerifyAccessToken("1", "token"); status != test.want {
+				t.Errorf("VerifyAccessToken() = %v, want %v", status, test.want)
+			}
+		})
+	}
+}
+
+func TestNewFacebookTokenVerifierRequiredScopes(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want []string
+	}{
+		{"unset", "", []string{}},
+		{"list", "public_profile, email,", []string{"public_profile", "email"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("FACEBOOK_REQUIRED_SCOPES", test.env)
+			if scopes := NewFacebookTokenVerifier().RequiredScopes; !reflect.DeepEqual(scopes, test.want) {
+				t.Errorf("RequiredScopes = %q, want %q", scopes, test.want)
+			}
+		})
+	}
+}
+
+func TestFacebookInfoAppAccessToken(t *testing.T) {
+	if token := testFacebookInfo.AppAccessToken(); token != "app-1|app-secret" {
+		t.Errorf("AppAccessToken() = %q, want app-1|app-secret", token)
+	}
+
+	// HMAC-SHA256 of app-1|app-secret keyed with app-secret
+	want := "5d6e55e154b87aa411e398e8e22b765f4d5c3ada34612a756676dc37c0144153"
+	if proof := testFacebookInfo.AppSecretProof("app-1|app-secret"); proof != want {
+		t.Errorf("AppSecretProof() = %q, want %q", proof, want)
+	}
+}
+
+func TestFacebookTokenVerifierRequest(t *testing.T) {
+	verifier, graph := newTestFacebookVerifier(t, map[string]string{"user token&1": debugToken(t, testFacebookInfo.AppID, "1", 0)})
+	if status := verifier.VerifyAccessToken("1", "user token&1"); status != identity.TokenValid {
+		t.Fatalf("VerifyAccessToken() = %v, want valid", status)
+	}
+	if graph.requestCount() != 1 {
+		t.Fatalf("debug_token requests = %d, want 1", graph.requestCount())
+	}
+
+	request := graph.requests[0]
+	if auth := request.Header.Get("Authorization"); auth != "OAuth "+testFacebookInfo.AppAccessToken() {
+		t.Errorf("Authorization = %q, want the app access token", auth)
+	}
+
+	query := request.URL.Query()
+	if proof := query.Get("appsecret_proof"); proof != testFacebookInfo.AppSecretProof(testFacebookInfo.AppAccessToken()) {
+		t.Errorf("appsecret_proof = %q, want the proof of the app access token", proof)
+	}
+	if strings.Contains(request.URL.RawQuery, testFacebookInfo.AppSecret) {
+		t.Errorf("Query %q holds the app secret", request.URL.RawQuery)
+	}
+}
+
+func TestNewFacebookTokenVerifierTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want time.Duration
+	}{
+		{"unset", "", defaultFacebookGraphTimeout},
+		{"duration", "3s", 3 * time.Second},
+		{"not a duration", "3", defaultFacebookGraphTimeout},
+		{"negative", "-1s", defaultFacebookGraphTimeout},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("FACEBOOK_GRAPH_TIMEOUT", test.env)
+			if timeout := NewFacebookTokenVerifier().Client.Timeout; timeout != test.want {
+				t.Errorf("Client.Timeout = %v, want %v", timeout, test.want)
+			}
+		})
+	}
+}
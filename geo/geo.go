@@ -0,0 +1,87 @@
+package geo
+
+import (
+	"math"
+)
+
+// Mean earth radius
+const earthRadiusKm = 6371.0
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// HaversineKm - Great circle distance between two points in kilometres
+func HaversineKm(lat1 float64, long1 float64, lat2 float64, long2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLong := toRadians(long2 - long1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLong/2)*math.Sin(dLong/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// BoundingBox - Lat/long box in degrees. MinLong may be below -180 or MaxLong above 180 if the box crosses the antimeridian.
+type BoundingBox struct {
+	MinLat  float64
+	MaxLat  float64
+	MinLong float64
+	MaxLong float64
+}
+
+// BoundingBoxAround - Smallest lat/long box enclosing every point within radiusKm of (lat, long)
+// See http://janmatuschek.de/LatitudeLongitudeBoundingCoordinates
+func BoundingBoxAround(lat float64, long float64, radiusKm float64) BoundingBox {
+	// Angular radius of the circle
+	radius := radiusKm / earthRadiusKm
+
+	minLat := lat - toDegrees(radius)
+	maxLat := lat + toDegrees(radius)
+
+	// Circle covers a pole, so every longitude is in range
+	if minLat <= -90 || maxLat >= 90 {
+		return BoundingBox{MinLat: math.Max(minLat, -90), MaxLat: math.Min(maxLat, 90), MinLong: -180, MaxLong: 180}
+	}
+
+	// Longitude degrees shrink towards the poles
+	sinDLong := math.Sin(radius) / math.Cos(toRadians(lat))
+	if sinDLong >= 1 {
+		return BoundingBox{MinLat: minLat, MaxLat: maxLat, MinLong: -180, MaxLong: 180}
+	}
+
+	dLong := toDegrees(math.Asin(sinDLong))
+	return BoundingBox{MinLat: minLat, MaxLat: maxLat, MinLong: long - dLong, MaxLong: long + dLong}
+}
+
+// MinDistanceToBoxKm - Lower bound in kilometres of the distance from (lat, long) to any point in the box, 0 inside it.
+// Takes the larger of the latitude gap and the distance to the great circle of the nearest meridian edge,
+// neither of which a path into the box can be shorter than.
+func MinDistanceToBoxKm(lat float64, long float64, box BoundingBox) float64 {
+	latGap := 0.0
+	if lat < box.MinLat {
+		latGap = box.MinLat - lat
+	} else if lat > box.MaxLat {
+		latGap = lat - box.MaxLat
+	}
+	bound := toRadians(latGap) * earthRadiusKm
+
+	// Longitude gap to the nearest edge, the short way around
+	longGap := 360.0
+	for _, offset := range []float64{-360, 0, 360} {
+		shifted := long + offset
+		if shifted >= box.MinLong && shifted <= box.MaxLong {
+			longGap = 0
+			break
+		}
+		longGap = math.Min(longGap, math.Min(math.Abs(shifted-box.MinLong), math.Abs(shifted-box.MaxLong)))
+	}
+	if longGap > 0 {
+		crossTrack := earthRadiusKm * math.Asin(math.Abs(math.Cos(toRadians(lat))*math.Sin(toRadians(math.Min(longGap, 90)))))
+		bound = math.Max(bound, crossTrack)
+	}
+	return bound
+}
@@ -0,0 +1,125 @@
+package geo
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name                     string
+		lat1, long1, lat2, long2 float64
+		want                     float64
+	}{
+		{"same point", 1.3521, 103.8198, 1.3521, 103.8198, 0},
+		{"one degree of latitude", 0, 0, 1, 0, 111.19},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.19},
+		{"Singapore to Kuala Lumpur", 1.3521, 103.8198, 3.1390, 101.6869, 309.7},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := HaversineKm(test.lat1, test.long1, test.lat2, test.long2)
+			if math.Abs(got-test.want) > 0.5 {
+				t.Errorf("HaversineKm() = %.2f, want %.2f", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	tests := []struct {
+		name          string
+		lat, long, km float64
+		fullLongitude bool
+	}{
+		{"equator", 0, 0, 10, false},
+		{"Singapore", 1.3521, 103.8198, 5, false},
+		{"near the antimeridian", 10, 179.99, 50, false},
+		{"covers the north pole", 89.9, 0, 50, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			box := BoundingBoxAround(test.lat, test.long, test.km)
+			if test.fullLongitude != (box.MinLong == -180 && box.MaxLong == 180) {
+				t.Errorf("BoundingBoxAround() = %+v, want every longitude %v", box, test.fullLongitude)
+			}
+
+			// Points on the circle must be inside the box
+			for bearing := 0.0; bearing < 360; bearing += 15 {
+				lat, long := destination(test.lat, test.long, bearing, test.km*0.999)
+				if !boxContains(box, lat, long) {
+					t.Errorf("BoundingBoxAround() = %+v, misses (%f, %f) at bearing %.0f", box, lat, long, bearing)
+				}
+			}
+		})
+	}
+}
+
+func TestMinDistanceToBoxKm(t *testing.T) {
+	box := BoundingBox{MinLat: 1, MaxLat: 2, MinLong: 103, MaxLong: 104}
+
+	tests := []struct {
+		name      string
+		lat, long float64
+		want      float64
+	}{
+		{"inside", 1.5, 103.5, 0},
+		{"on the edge", 1, 103.5, 0},
+		{"south of the box", 0, 103.5, 111.19},
+		{"west of the box on the equator", 1.5, 102, 111.16},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := MinDistanceToBoxKm(test.lat, test.long, box)
+			if math.Abs(got-test.want) > 0.5 {
+				t.Errorf("MinDistanceToBoxKm() = %.2f, want %.2f", got, test.want)
+			}
+		})
+	}
+}
+
+// The geohash search skips cells whose bound is past the search distance, so the bound must never overshoot
+func TestMinDistanceToBoxKmIsLowerBound(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		lat, long := random.Float64()*170-85, random.Float64()*360-180
+		cell := EncodeGeohash(random.Float64()*170-85, random.Float64()*360-180, 1+random.Intn(4))
+		box := GeohashCellBox(cell)
+		bound := MinDistanceToBoxKm(lat, long, box)
+
+		for j := 0; j < 20; j++ {
+			pointLat := box.MinLat + random.Float64()*(box.MaxLat-box.MinLat)
+			pointLong := box.MinLong + random.Float64()*(box.MaxLong-box.MinLong)
+			if distance := HaversineKm(lat, long, pointLat, pointLong); distance < bound-1e-6 {
+				t.Fatalf("MinDistanceToBoxKm(%f, %f, %s) = %f, but (%f, %f) is %f away", lat, long, cell, bound, pointLat, pointLong, distance)
+			}
+		}
+	}
+}
+
+// destination - Point distanceKm from (lat, long) along bearing degrees
+func destination(lat float64, long float64, bearing float64, distanceKm float64) (float64, float64) {
+	angle := distanceKm / earthRadiusKm
+	lat1, long1, theta := toRadians(lat), toRadians(long), toRadians(bearing)
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angle) + math.Cos(lat1)*math.Sin(angle)*math.Cos(theta))
+	long2 := long1 + math.Atan2(math.Sin(theta)*math.Sin(angle)*math.Cos(lat1), math.Cos(angle)-math.Sin(lat1)*math.Sin(lat2))
+	return toDegrees(lat2), toDegrees(long2)
+}
+
+// boxContains - Whether the box holds (lat, long), trying the longitude a turn either way for boxes across the antimeridian
+func boxContains(box BoundingBox, lat float64, long float64) bool {
+	if lat < box.MinLat || lat > box.MaxLat {
+		return false
+	}
+	for _, offset := range []float64{-360, 0, 360} {
+		if long+offset >= box.MinLong && long+offset <= box.MaxLong {
+			return true
+		}
+	}
+	return false
+}
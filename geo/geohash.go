@@ -0,0 +1,131 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashPrecision - Characters of the geohash stored on each place
+const GeohashPrecision = 9
+
+// GeohashIndexPrecision - Characters of the geohash prefix used as the geohash index partition key
+const GeohashIndexPrecision = 4
+
+// EncodeGeohash - Geohash of (lat, long) with precision characters
+func EncodeGeohash(lat float64, long float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLong, maxLong := -180.0, 180.0
+
+	hash := make([]byte, 0, precision)
+	bit, ch := 0, 0
+	evenBit := true
+	for len(hash) < precision {
+		// Bits alternate between longitude and latitude, starting with longitude
+		if evenBit {
+			mid := (minLong + maxLong) / 2
+			if long >= mid {
+				ch = ch<<1 | 1
+				minLong = mid
+			} else {
+				ch = ch << 1
+				maxLong = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch = ch << 1
+				maxLat = mid
+			}
+		}
+		evenBit = !evenBit
+
+		bit++
+		if bit == 5 {
+			hash = append(hash, geohashBase32[ch])
+			bit, ch = 0, 0
+		}
+	}
+
+	return string(hash)
+}
+
+// GeohashCellSize - Height and width in degrees of a geohash cell with precision characters
+func GeohashCellSize(precision int) (float64, float64) {
+	bits := 5 * precision
+	latBits := bits / 2
+	longBits := bits - latBits
+	return 180 / math.Exp2(float64(latBits)), 360 / math.Exp2(float64(longBits))
+}
+
+// GeohashCoveringCells - Geohash cells with precision characters that together cover the box
+func GeohashCoveringCells(box BoundingBox, precision int) []string {
+	cellLat, cellLong := GeohashCellSize(precision)
+
+	seen := map[string]bool{}
+	cells := []string{}
+	for lat := box.MinLat; ; lat += cellLat {
+		lat = math.Min(lat, box.MaxLat)
+		for long := box.MinLong; ; long += cellLong {
+			long = math.Min(long, box.MaxLong)
+
+			// Wrap longitudes of boxes across the antimeridian back into -180 to 180
+			wrappedLong := long
+			if wrappedLong < -180 {
+				wrappedLong += 360
+			} else if wrappedLong >= 180 {
+				wrappedLong -= 360
+			}
+
+			cell := EncodeGeohash(lat, wrappedLong, precision)
+			if !seen[cell] {
+				seen[cell] = true
+				cells = append(cells, cell)
+			}
+
+			if long >= box.MaxLong {
+				break
+			}
+		}
+
+		if lat >= box.MaxLat {
+			break
+		}
+	}
+
+	return cells
+}
+
+// GeohashCellBox - Lat/long box of the geohash cell
+func GeohashCellBox(cell string) BoundingBox {
+	box := BoundingBox{MinLat: -90, MaxLat: 90, MinLong: -180, MaxLong: 180}
+	evenBit := true
+	for i := 0; i < len(cell); i++ {
+		ch := strings.IndexByte(geohashBase32, cell[i])
+		for bit := 4; bit >= 0; bit-- {
+			// Bits alternate between longitude and latitude, starting with longitude
+			set := ch>>uint(bit)&1 == 1
+			if evenBit {
+				mid := (box.MinLong + box.MaxLong) / 2
+				if set {
+					box.MinLong = mid
+				} else {
+					box.MaxLong = mid
+				}
+			} else {
+				mid := (box.MinLat + box.MaxLat) / 2
+				if set {
+					box.MinLat = mid
+				} else {
+					box.MaxLat = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+	}
+	return box
+}
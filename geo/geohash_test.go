@@ -0,0 +1,103 @@
+package geo
+
+import (
+	"testing"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	tests := []struct {
+		name      string
+		lat, long float64
+		precision int
+		want      string
+	}{
+		{"Gardens by the Bay", 1.2816, 103.8636, 9, "w21z73r1v"},
+		{"origin", 0, 0, 4, "s000"},
+		{"south west corner", -90, -180, 4, "0000"},
+		{"Jutland", 57.64911, 10.40744, 11, "u4pruydqqvj"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := EncodeGeohash(test.lat, test.long, test.precision); got != test.want {
+				t.Errorf("EncodeGeohash() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGeohashCellBox(t *testing.T) {
+	tests := []struct {
+		cell string
+	}{
+		{"w"}, {"w21z"}, {"s000"}, {"0000"}, {"zzzz"}, {"w21z73r1v"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cell, func(t *testing.T) {
+			box := GeohashCellBox(test.cell)
+
+			height, width := GeohashCellSize(len(test.cell))
+			if !almostEqual(box.MaxLat-box.MinLat, height) || !almostEqual(box.MaxLong-box.MinLong, width) {
+				t.Errorf("GeohashCellBox(%q) = %+v, want %f by %f degrees", test.cell, box, height, width)
+			}
+
+			// Every corner just inside the box encodes back to the cell
+			inset := height / 1000
+			corners := [][2]float64{
+				{box.MinLat + inset, box.MinLong + inset},
+				{box.MinLat + inset, box.MaxLong - inset},
+				{box.MaxLat - inset, box.MinLong + inset},
+				{box.MaxLat - inset, box.MaxLong - inset},
+			}
+			for _, corner := range corners {
+				if got := EncodeGeohash(corner[0], corner[1], len(test.cell)); got != test.cell {
+					t.Errorf("GeohashCellBox(%q) corner (%f, %f) encodes to %q", test.cell, corner[0], corner[1], got)
+				}
+			}
+		})
+	}
+}
+
+func TestGeohashCoveringCells(t *testing.T) {
+	tests := []struct {
+		name          string
+		lat, long, km float64
+	}{
+		{"Singapore", 1.3521, 103.8198, 5},
+		{"across a cell edge", 0, 0, 30},
+		{"across the antimeridian", 0, 179.9, 30},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			box := BoundingBoxAround(test.lat, test.long, test.km)
+			cells := GeohashCoveringCells(box, GeohashIndexPrecision)
+
+			covered := map[string]bool{}
+			for _, cell := range cells {
+				covered[cell] = true
+			}
+
+			// Every point within the radius is in one of the cells
+			for bearing := 0.0; bearing < 360; bearing += 10 {
+				for _, fraction := range []float64{0, 0.5, 0.999} {
+					lat, long := destination(test.lat, test.long, bearing, test.km*fraction)
+					if long >= 180 {
+						long -= 360
+					} else if long < -180 {
+						long += 360
+					}
+					if cell := EncodeGeohash(lat, long, GeohashIndexPrecision); !covered[cell] {
+						t.Errorf("GeohashCoveringCells() = %v, misses %q at (%f, %f)", cells, cell, lat, long)
+					}
+				}
+			}
+		})
+	}
+}
+
+func almostEqual(a float64, b float64) bool {
+	diff := a - b
+	return diff < 1e-9 && diff > -1e-9
+}
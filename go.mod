@@ -0,0 +1,10 @@
+module github.com/shikang/travote-be
+
+go 1.21
+
+require (
+	github.com/aws/aws-lambda-go v1.47.0
+	github.com/aws/aws-sdk-go v1.55.5
+)
+
+require github.com/jmespath/go-jmespath v0.4.0 // indirect
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/utils"
+)
+
+// countryParamRules - Rules of the query string parameters of HandleGetCountriesRequest
+var countryParamRules = utils.Rules{
+	"abbr":  {utils.ListLength(1, maxInValues)},
+	"name":  {utils.ListLength(1, maxInValues)},
+	"limit": {utils.IntRange(1, utils.MaxPageLimit)},
+}
+
+// CountriesHandler - Countries lambda, reading from Countries
+type CountriesHandler struct {
+	Countries store.CountryStore
+}
+
+// GetCountriesResponse - Get response
+func (handler CountriesHandler) GetCountriesResponse(request events.APIGatewayProxyRequest, query store.CountryQuery, limit int64, nextToken string) (events.APIGatewayProxyResponse, error) {
+	countries, nextToken, err := handler.Countries.GetCountries(query, limit, nextToken)
+	if err != nil {
+		return utils.GenerateErrorResponse(request, err)
+	}
+
+	return utils.GenerateResponse(request, countries, nextToken)
+}
+
+// HandleGetCountriesRequest - Lambda function
+func (handler CountriesHandler) HandleGetCountriesRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if request.HTTPMethod == "GET" {
+		query, err := ParseCountryQuery(request.QueryStringParameters)
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		}
+		queryLimit := utils.IntParam(request.QueryStringParameters, "limit", 10)
+		nextToken := request.QueryStringParameters["next_token"]
+
+		if query.IsEmpty() {
+			logging.FromContext(ctx).Info("Get countries without filter", nil)
+		} else {
+			logging.FromContext(ctx).Info("Get countries with filter", logging.Fields{"filter": query})
+		}
+		return handler.GetCountriesResponse(request, query, queryLimit, nextToken)
+	} else {
+		return utils.GenerateErrorResponse(request, utils.NewMethodNotAllowedError(request.HTTPMethod, "GET"))
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/utils"
+)
+
+func TestHandleGetCountriesRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		abbrs   []string
+		hasNext bool
+	}{
+		{"every country", map[string]string{}, []string{"MY", "SG", "TH"}, false},
+		{"first page", map[string]string{"limit": "2"}, []string{"MY", "SG"}, true},
+		{"abbr list", map[string]string{"abbr": "SG, TH"}, []string{"SG", "TH"}, false},
+		{"name", map[string]string{"name": "Malaysia"}, []string{"MY"}, false},
+		{"name prefix", map[string]string{"name_prefix": "Sing"}, []string{"SG"}, false},
+		{"name prefix is case sensitive", map[string]string{"name_prefix": "sing"}, []string{}, false},
+		{"name search", map[string]string{"name_search": "LAND"}, []string{"TH"}, false},
+		{"filters combined", map[string]string{"abbr": "MY,SG", "name_search": "a"}, []string{"MY", "SG"}, false},
+	}
+
+	handler := CountriesHandler{Countries: newTestStore()}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, _ := handler.HandleGetCountriesRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: test.params})
+			envelope := decodeResponse(t, response, http.StatusOK, "")
+
+			if abbrs := responseIDs(t, envelope); !reflect.DeepEqual(abbrs, test.abbrs) {
+				t.Errorf("HandleGetCountriesRequest() countries = %v, want %v", abbrs, test.abbrs)
+			}
+			if (envelope.NextToken != "") != test.hasNext {
+				t.Errorf("HandleGetCountriesRequest() next_token = %q, want one %v", envelope.NextToken, test.hasNext)
+			}
+		})
+	}
+}
+
+func TestHandleGetCountriesRequestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		params map[string]string
+		status int
+		code   utils.ErrorCode
+	}{
+		{"limit too small", "GET", map[string]string{"limit": "0"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter},
+		{"limit too large", "GET", map[string]string{"limit": "201"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter},
+		{"unknown parameter", "GET", map[string]string{"continent": "Asia"}, http.StatusBadRequest, utils.ErrorCodeUnsupportedFilter},
+		{"empty abbr list", "GET", map[string]string{"abbr": " , "}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter},
+		{"bad next_token", "GET", map[string]string{"next_token": "not-a-token"}, http.StatusBadRequest, utils.ErrorCodeInvalidNextToken},
+		{"POST", "POST", map[string]string{}, http.StatusMethodNotAllowed, utils.ErrorCodeMethodNotAllowed},
+	}
+
+	handler := CountriesHandler{Countries: newTestStore()}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, _ := handler.HandleGetCountriesRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: test.method, QueryStringParameters: test.params})
+			decodeResponse(t, response, test.status, test.code)
+		})
+	}
+}
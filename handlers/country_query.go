@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/utils"
+)
+
+// DynamoDB allows at most 100 operands for IN
+const maxInValues = 100
+
+// countryQueryParams - Query string parameters understood by HandleGetCountriesRequest
+var countryQueryParams = []string{"abbr", "name", "name_prefix", "name_search", "limit", "next_token"}
+
+// ParseCountryQuery - store.CountryQuery from query string parameters checked by countryParamRules,
+// unknown parameters are rejected
+func ParseCountryQuery(params map[string]string) (store.CountryQuery, error) {
+	unknown := []string{}
+	for param := range params {
+		if !utils.ContainsString(countryQueryParams, param) {
+			unknown = append(unknown, param)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return store.CountryQuery{}, utils.NewValidationError(utils.ErrorCodeUnsupportedFilter, "Unsupported parameters: "+strings.Join(unknown, ", ")+", allowed parameters: "+strings.Join(countryQueryParams, ", "))
+	}
+
+	err := countryParamRules.Validate(params)
+	if err != nil {
+		return store.CountryQuery{}, err
+	}
+
+	query := store.CountryQuery{
+		NamePrefix: params["name_prefix"],
+		NameSearch: params["name_search"],
+	}
+	if abbr, ok := params["abbr"]; ok {
+		query.Abbrs = utils.SplitList(abbr)
+	}
+	if name, ok := params["name"]; ok {
+		query.Names = utils.SplitList(name)
+	}
+	return query, nil
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/utils"
+)
+
+func TestParseCountryQuery(t *testing.T) {
+	tooMany := []string{}
+	for i := 0; i <= maxInValues; i++ {
+		tooMany = append(tooMany, "C"+strconv.Itoa(i))
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   store.CountryQuery
+		code   utils.ErrorCode
+	}{
+		{"empty", map[string]string{}, store.CountryQuery{}, ""},
+		{"paging only", map[string]string{"limit": "5", "next_token": "abc"}, store.CountryQuery{}, ""},
+		{"lists", map[string]string{"abbr": "SG, MY,", "name": "Singapore"}, store.CountryQuery{Abbrs: []string{"SG", "MY"}, Names: []string{"Singapore"}}, ""},
+		{"name filters", map[string]string{"name_prefix": "Sing", "name_search": "pore"}, store.CountryQuery{NamePrefix: "Sing", NameSearch: "pore"}, ""},
+		{"empty list", map[string]string{"abbr": ","}, store.CountryQuery{}, utils.ErrorCodeInvalidParameter},
+		{"too many values", map[string]string{"abbr": strings.Join(tooMany, ",")}, store.CountryQuery{}, utils.ErrorCodeInvalidParameter},
+		{"invalid limit", map[string]string{"abbr": "SG", "limit": "0"}, store.CountryQuery{}, utils.ErrorCodeInvalidParameter},
+		{"unknown parameter", map[string]string{"continent": "Asia"}, store.CountryQuery{}, utils.ErrorCodeUnsupportedFilter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := ParseCountryQuery(test.params)
+			code := utils.ErrorCode("")
+			if err != nil {
+				code = utils.AsAPIError(err).Code
+			}
+			if code != test.code {
+				t.Fatalf("ParseCountryQuery() = %v, want code %q", err, test.code)
+			}
+			if !reflect.DeepEqual(query, test.want) {
+				t.Errorf("ParseCountryQuery() = %+v, want %+v", query, test.want)
+			}
+
+			// name_search is left to Matches, DynamoDB can't evaluate it
+			wantCondition := len(query.Abbrs) > 0 || len(query.Names) > 0 || query.NamePrefix != ""
+			if _, ok := query.Condition(); ok != wantCondition {
+				t.Errorf("Condition() = %v for %+v, want %v", ok, query, wantCondition)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/session"
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+// testPlaces - Places of newTestStore. sg-6 and sg-7 are below sg-1, and sg-8 below sg-6.
+var testPlaces = []structs.Place{
+	{ID: "sg-1", Abbr: "SG", Name: "Gardens by the Bay", Category: "Nature", Zone: "Central", Lat: 1.2816, Long: 103.8636, Votes: 3},
+	{ID: "sg-2", Abbr: "SG", Name: "Marina Bay Sands", Category: "Hotel", Zone: "Central", Lat: 1.2834, Long: 103.8607, Votes: 5},
+	{ID: "sg-3", Abbr: "SG", Name: "Sentosa", Category: "Nature", Zone: "South", Lat: 1.2494, Long: 103.8303, Votes: 1},
+	{ID: "sg-4", Abbr: "SG", Name: "Jewel Changi", Category: "Shopping", Zone: "East", Lat: 1.3602, Long: 103.9898},
+	{ID: "sg-5", Abbr: "SG", Name: "Old Ford Factory", Category: "History", Zone: "North", Lat: 1.3509, Long: 103.7695, Inactive: true},
+	{ID: "sg-6", Abbr: "SG", Name: "Cloud Forest", Master: "sg-1", Category: "Nature", Zone: "Central", Lat: 1.2841, Long: 103.8656, Votes: 2},
+	{ID: "sg-7", Abbr: "SG", Name: "Flower Dome", Master: "sg-1", Category: "Nature", Zone: "Central", Lat: 1.2845, Long: 103.8646},
+	{ID: "sg-8", Abbr: "SG", Name: "Cloud Walk", Master: "sg-6", Category: "Nature", Zone: "Central", Lat: 1.2842, Long: 103.8657, Votes: 1},
+	{ID: "my-1", Abbr: "MY", Name: "Petronas Towers", Category: "Landmark", Zone: "Central", Lat: 3.1579, Long: 101.7116, Votes: 4},
+}
+
+// testCountries - Countries of newTestStore
+var testCountries = []structs.Country{
+	{Abbr: "MY", Name: "Malaysia"},
+	{Abbr: "SG", Name: "Singapore"},
+	{Abbr: "TH", Name: "Thailand"},
+}
+
+// newTestStore - MemoryStore of testPlaces and testCountries
+func newTestStore() *store.MemoryStore {
+	memory := store.NewMemoryStore()
+	for _, place := range testPlaces {
+		memory.PutPlace(place)
+	}
+	for _, country := range testCountries {
+		memory.PutCountry(country)
+	}
+	return memory
+}
+
+// testTokenVerifier - TokenVerifier accepting the token "valid", and failing "expired" and "wrong_app" with that status
+type testTokenVerifier struct{}
+
+func (verifier testTokenVerifier) VerifyAccessToken(userID string, accessToken string) identity.TokenStatus {
+	switch accessToken {
+	case "valid":
+		return identity.TokenValid
+	case "expired":
+		return identity.TokenExpired
+	case "wrong_app":
+		return identity.TokenWrongApp
+	default:
+		return identity.TokenInvalid
+	}
+}
+
+var testIdentities = identity.IdentityVerifiers{identity.ProviderFacebook: testTokenVerifier{}, identity.ProviderGoogle: testTokenVerifier{}}
+
+func newTestSessionSigner() *session.SessionSigner {
+	return &session.SessionSigner{
+		GetKeyInfo: func() (session.SessionKeyInfo, error) {
+			return session.SessionKeyInfo{SigningKey: "travote-test-session-signing-key"}, nil
+		},
+	}
+}
+
+// testEnvelope - utils.Envelope with Data left as JSON
+type testEnvelope struct {
+	Data      json.RawMessage      `json:"data"`
+	Error     *utils.ResponseError `json:"error"`
+	NextToken string               `json:"next_token"`
+}
+
+// decodeResponse - Envelope of response, failing the test if its status is not status or its error code is not code
+func decodeResponse(t *testing.T, response events.APIGatewayProxyResponse, status int, code utils.ErrorCode) testEnvelope {
+	t.Helper()
+	envelope := testEnvelope{}
+	err := json.Unmarshal([]byte(response.Body), &envelope)
+	if err != nil {
+		t.Fatalf("Decoding response body %q failed: %v", response.Body, err)
+	}
+
+	gotCode := utils.ErrorCode("")
+	if envelope.Error != nil {
+		gotCode = envelope.Error.Code
+	}
+	if response.StatusCode != status || gotCode != code {
+		t.Fatalf("Response status, code = %d, %q, want %d, %q: %s", response.StatusCode, gotCode, status, code, response.Body)
+	}
+	return envelope
+}
+
+// responseIDs - IDs of the places or countries in the envelope's data
+func responseIDs(t *testing.T, envelope testEnvelope) []string {
+	t.Helper()
+	items := []struct {
+		ID   string `json:"id"`
+		Abbr string `json:"abbr"`
+	}{}
+	err := json.Unmarshal(envelope.Data, &items)
+	if err != nil {
+		t.Fatalf("Decoding data %s failed: %v", envelope.Data, err)
+	}
+
+	ids := []string{}
+	for _, item := range items {
+		if item.ID != "" {
+			ids = append(ids, item.ID)
+		} else {
+			ids = append(ids, item.Abbr)
+		}
+	}
+	return ids
+}
+
+// newTestVotesHandler - VotesHandler of newTestStore, and the store
+func newTestVotesHandler() (VotesHandler, *store.MemoryStore) {
+	memory := newTestStore()
+	return VotesHandler{Votes: memory, Places: memory, Identities: testIdentities, Sessions: newTestSessionSigner()}, memory
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/structs"
+)
+
+// Votes fetched per page, GetPlacesByKeys batches at most 100 places
+const maxVoteHistoryLimit = 100
+
+// GetVoteHistory - One page of the user's voted places, grouped by country abbr.
+// Places deleted since the vote are left out. limit is at most maxVoteHistoryLimit, checked by voteParamRules.
+func (handler VotesHandler) GetVoteHistory(userID string, limit int64, nextToken string) (map[string][]structs.Place, string, error) {
+	votes, nextToken, err := handler.Votes.GetUserVotes(userID, limit, nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	keys := []store.PlaceKey{}
+	for _, vote := range votes {
+		keys = append(keys, store.PlaceKey{Abbr: vote.Abbr, ID: vote.PlaceID})
+	}
+
+	places, err := handler.Places.GetPlacesByKeys(keys)
+	if err != nil {
+		return nil, "", err
+	}
+
+	placesByAbbr := map[string][]structs.Place{}
+	for _, place := range places {
+		placesByAbbr[place.Abbr] = append(placesByAbbr[place.Abbr], place)
+	}
+
+	return placesByAbbr, nextToken, nil
+}
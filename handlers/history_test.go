@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/utils"
+)
+
+func TestHandleVotePlaceRequestHistory(t *testing.T) {
+	signer := newTestSessionSigner()
+	issued, err := signer.IssueSession(identity.VoterID(identity.ProviderGoogle, "1"))
+	if err != nil {
+		t.Fatalf("IssueSession() = %v", err)
+	}
+	credentials := map[string]string{identity.ProviderHeader: "google", identity.UserIDHeader: "1", identity.TokenHeader: "valid"}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		params  map[string]string
+		status  int
+		code    utils.ErrorCode
+		places  map[string][]string
+		hasNext bool
+	}{
+		{"credential headers", credentials, nil, http.StatusOK, "", map[string][]string{"SG": {"sg-1", "sg-2"}, "MY": {"my-1"}}, false},
+		{"lower case headers", map[string]string{"x-identity-provider": "google", "x-identity-user-id": "1", "x-identity-token": "valid"}, nil, http.StatusOK, "", map[string][]string{"SG": {"sg-1", "sg-2"}, "MY": {"my-1"}}, false},
+		{"session", map[string]string{"Authorization": "Bearer " + issued.AccessToken}, nil, http.StatusOK, "", map[string][]string{"SG": {"sg-1", "sg-2"}, "MY": {"my-1"}}, false},
+		{"first page", credentials, map[string]string{"limit": "2"}, http.StatusOK, "", map[string][]string{"SG": {"sg-1"}, "MY": {"my-1"}}, true},
+		{"another voter", map[string]string{identity.ProviderHeader: "google", identity.UserIDHeader: "2", identity.TokenHeader: "valid"}, nil, http.StatusOK, "", map[string][]string{}, false},
+		{"credentials in the query string", nil, map[string]string{"provider": "google", "user_id": "1", "token": "valid"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, nil, false},
+		{"legacy credentials in the query string", credentials, map[string]string{"fb_access_token": "valid"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, nil, false},
+		{"no credentials", nil, nil, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, nil, false},
+		{"no token header", map[string]string{identity.UserIDHeader: "1"}, nil, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, nil, false},
+		{"expired token", map[string]string{identity.ProviderHeader: "google", identity.UserIDHeader: "1", identity.TokenHeader: "expired"}, nil, http.StatusUnauthorized, utils.ErrorCodeTokenExpired, nil, false},
+		{"largest limit", credentials, map[string]string{"limit": "100"}, http.StatusOK, "", map[string][]string{"SG": {"sg-1", "sg-2"}, "MY": {"my-1"}}, false},
+		{"limit too large", credentials, map[string]string{"limit": "101"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, memory := newTestVotesHandler()
+			for _, key := range []store.PlaceKey{{Abbr: "SG", ID: "sg-1"}, {Abbr: "SG", ID: "sg-2"}, {Abbr: "MY", ID: "my-1"}} {
+				err := memory.Vote(identity.VoterID(identity.ProviderGoogle, "1"), key)
+				if err != nil {
+					t.Fatalf("Vote() = %v", err)
+				}
+			}
+
+			response, _ := handler.HandleVotePlaceRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", Headers: test.headers, QueryStringParameters: test.params})
+			envelope := decodeResponse(t, response, test.status, test.code)
+			if test.code != "" {
+				return
+			}
+
+			history := map[string][]struct {
+				ID string `json:"id"`
+			}{}
+			err := json.Unmarshal(envelope.Data, &history)
+			if err != nil {
+				t.Fatalf("Decoding history %s failed: %v", envelope.Data, err)
+			}
+
+			places := map[string][]string{}
+			for abbr, voted := range history {
+				for _, place := range voted {
+					places[abbr] = append(places[abbr], place.ID)
+				}
+				sort.Strings(places[abbr])
+			}
+			if !reflect.DeepEqual(places, test.places) {
+				t.Errorf("HandleVotePlaceRequest() history = %v, want %v", places, test.places)
+			}
+			if (envelope.NextToken != "") != test.hasNext {
+				t.Errorf("HandleVotePlaceRequest() next_token = %q, want one %v", envelope.NextToken, test.hasNext)
+			}
+		})
+	}
+}
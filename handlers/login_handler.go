@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/session"
+	"github.com/shikang/travote-be/utils"
+)
+
+// LoginAPIParams - Caps for field names, because of json.Marshal requirements.
+// Either the identity provider's token, or refresh_token from an earlier login.
+type LoginAPIParams struct {
+	identity.IdentityParams
+	RefreshToken string `json:"refresh_token"`
+}
+
+// loginBodyRules - Rules of the LoginAPIParams body, refresh_token and credentials are checked by HandleLoginRequest
+var loginBodyRules = utils.Rules{}
+
+// LoginHandler - Login lambda, exchanging an identity provider token verified with Identities for a Travote session
+type LoginHandler struct {
+	Identities identity.IdentityVerifiers
+	Sessions   *session.SessionSigner
+}
+
+// HandleLoginRequest - Lambda function
+func (handler LoginHandler) HandleLoginRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if request.HTTPMethod == "POST" {
+		params := LoginAPIParams{}
+		err := loginBodyRules.DecodeBody(request.Body, &params)
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		}
+
+		var issued session.Session
+		if params.RefreshToken != "" {
+			var status identity.TokenStatus
+			issued, status, err = handler.Sessions.RefreshSession(params.RefreshToken)
+			if err == nil && status != identity.TokenValid {
+				logging.FromContext(ctx).Warn("Refresh token rejected", logging.Fields{"status": status.String()})
+				return utils.GenerateErrorResponse(request, tokenError(status))
+			}
+		} else {
+			err = params.Validate()
+			if err != nil {
+				return utils.GenerateErrorResponse(request, err)
+			}
+
+			provider, userID, token := params.Credentials()
+			status, ok := handler.Identities.Verify(provider, userID, token)
+			if !ok {
+				return utils.GenerateErrorResponse(request, utils.NewValidationError(utils.ErrorCodeUnsupportedProvider, "Unsupported provider: "+provider))
+			} else if status != identity.TokenValid {
+				logging.FromContext(ctx).Warn("Token rejected", logging.Fields{"voter_id": identity.VoterID(provider, userID), "status": status.String()})
+				return utils.GenerateErrorResponse(request, tokenError(status))
+			}
+
+			logging.FromContext(ctx).Info("Login", logging.Fields{"voter_id": identity.VoterID(provider, userID)})
+			issued, err = handler.Sessions.IssueSession(identity.VoterID(provider, userID))
+		}
+
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		}
+
+		apiResponse, err := utils.GenerateResponse(request, issued, "")
+		apiResponse.Headers["Cache-Control"] = "no-store"
+		return apiResponse, err
+	} else {
+		return utils.GenerateErrorResponse(request, utils.NewMethodNotAllowedError(request.HTTPMethod, "POST"))
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/session"
+	"github.com/shikang/travote-be/utils"
+)
+
+func TestHandleLoginRequest(t *testing.T) {
+	signer := newTestSessionSigner()
+	issued, err := signer.IssueSession(identity.VoterID(identity.ProviderGoogle, "1"))
+	if err != nil {
+		t.Fatalf("IssueSession() = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		status  int
+		code    utils.ErrorCode
+		voterID string
+	}{
+		{"provider token", "POST", `{"provider":"google","user_id":"1","token":"valid"}`, http.StatusOK, "", "google#1"},
+		{"legacy facebook token", "POST", `{"fb_id":"2","fb_access_token":"valid"}`, http.StatusOK, "", "facebook#2"},
+		{"refresh token", "POST", `{"refresh_token":"` + issued.RefreshToken + `"}`, http.StatusOK, "", "google#1"},
+		{"session token as refresh token", "POST", `{"refresh_token":"` + issued.AccessToken + `"}`, http.StatusUnauthorized, utils.ErrorCodeInvalidToken, ""},
+		{"expired provider token", "POST", `{"provider":"google","user_id":"1","token":"expired"}`, http.StatusUnauthorized, utils.ErrorCodeTokenExpired, ""},
+		{"invalid provider token", "POST", `{"provider":"google","user_id":"1","token":"forged"}`, http.StatusUnauthorized, utils.ErrorCodeInvalidToken, ""},
+		{"unsupported provider", "POST", `{"provider":"apple","user_id":"1","token":"valid"}`, http.StatusBadRequest, utils.ErrorCodeUnsupportedProvider, ""},
+		{"no token", "POST", `{"provider":"google","user_id":"1"}`, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, ""},
+		{"not JSON", "POST", `token=valid`, http.StatusBadRequest, utils.ErrorCodeBadRequest, ""},
+		{"GET", "GET", ``, http.StatusMethodNotAllowed, utils.ErrorCodeMethodNotAllowed, ""},
+	}
+
+	handler := LoginHandler{Identities: testIdentities, Sessions: signer}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, _ := handler.HandleLoginRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: test.method, Body: test.body})
+			envelope := decodeResponse(t, response, test.status, test.code)
+			if test.code != "" {
+				return
+			}
+
+			if response.Headers["Cache-Control"] != "no-store" {
+				t.Errorf("HandleLoginRequest() Cache-Control = %q, want no-store", response.Headers["Cache-Control"])
+			}
+
+			loggedIn := session.Session{}
+			err := json.Unmarshal(envelope.Data, &loggedIn)
+			if err != nil {
+				t.Fatalf("Decoding session %s failed: %v", envelope.Data, err)
+			}
+			if voterID, status := signer.VerifySessionToken(loggedIn.AccessToken); status != identity.TokenValid || voterID != test.voterID {
+				t.Errorf("VerifySessionToken() = %q, %v, want %q, valid", voterID, status, test.voterID)
+			}
+			if _, status, err := signer.RefreshSession(loggedIn.RefreshToken); status != identity.TokenValid || err != nil {
+				t.Errorf("RefreshSession() = %v, %v, want a valid refresh token", status, err)
+			}
+		})
+	}
+}
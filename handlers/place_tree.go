@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+// BuildPlaceTree - places nested under their master, with votes rolled up into TotalVotes.
+// Places whose master is not in places are roots, as is the first place of a master cycle.
+func BuildPlaceTree(places []structs.Place) []structs.PlaceNode {
+	ids := map[string]bool{}
+	for _, place := range places {
+		ids[place.ID] = true
+	}
+
+	children := map[string][]structs.Place{}
+	roots := []structs.Place{}
+	for _, place := range places {
+		if place.Master != "" && place.Master != place.ID && ids[place.Master] {
+			children[place.Master] = append(children[place.Master], place)
+		} else {
+			roots = append(roots, place)
+		}
+	}
+
+	visited := map[string]bool{}
+	var build func(place structs.Place) structs.PlaceNode
+	build = func(place structs.Place) structs.PlaceNode {
+		visited[place.ID] = true
+		node := structs.PlaceNode{Place: place, TotalVotes: place.Votes, Children: []structs.PlaceNode{}}
+		for _, child := range children[place.ID] {
+			if visited[child.ID] {
+				continue
+			}
+			childNode := build(child)
+			node.TotalVotes += childNode.TotalVotes
+			node.Children = append(node.Children, childNode)
+		}
+		return node
+	}
+
+	tree := []structs.PlaceNode{}
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+
+	// Places in a master cycle can't be reached from the roots
+	for _, place := range places {
+		if !visited[place.ID] {
+			tree = append(tree, build(place))
+		}
+	}
+	return tree
+}
+
+// GetDescendantPlaces - Every place below id, children before grandchildren.
+// Loads the country's master links in one query and walks them in memory. Countries with more links than
+// utils.MaxScanCapacityUnits reads are walked a place at a time instead, reading only the places below id.
+func (handler PlacesHandler) GetDescendantPlaces(abbr string, id string) ([]structs.Place, error) {
+	linked, complete, err := handler.Places.GetPlacesWithMaster(abbr)
+	if err != nil {
+		return nil, err
+	}
+
+	childPlaces := handler.GetChildPlaces
+	if complete {
+		children := map[string][]structs.Place{}
+		for _, place := range linked {
+			children[place.Master] = append(children[place.Master], place)
+		}
+		childPlaces = func(abbr string, master string) ([]structs.Place, error) {
+			return children[master], nil
+		}
+	}
+
+	places := []structs.Place{}
+	seen := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		children, err := childPlaces(abbr, queue[0])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, child := range children {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				places = append(places, child)
+				queue = append(queue, child.ID)
+			}
+		}
+		queue = queue[1:]
+	}
+	return places, nil
+}
+
+// GetChildPlaces - Every place whose master is master, reading every page
+func (handler PlacesHandler) GetChildPlaces(abbr string, master string) ([]structs.Place, error) {
+	places := []structs.Place{}
+	nextToken := ""
+	for {
+		var page []structs.Place
+		var err error
+		page, nextToken, err = handler.Places.GetPlacesWithFilter(abbr, "master", master, utils.MaxPageLimit, nextToken)
+		if err != nil {
+			return nil, err
+		}
+
+		places = append(places, page...)
+		if nextToken == "" {
+			return places, nil
+		}
+	}
+}
+
+// GetPlaceTree - A country's places nested under their master, or only the places below master if it is set
+func (handler PlacesHandler) GetPlaceTree(abbr string, master string) ([]structs.PlaceNode, error) {
+	if master != "" {
+		places, err := handler.GetDescendantPlaces(abbr, master)
+		if err != nil {
+			return nil, err
+		}
+		return BuildPlaceTree(places), nil
+	}
+
+	places := []structs.Place{}
+	nextToken := ""
+	for {
+		var page []structs.Place
+		var err error
+		page, nextToken, err = handler.Places.GetPlaces(abbr, utils.MaxPageLimit, nextToken)
+		if err != nil {
+			return nil, err
+		}
+
+		places = append(places, page...)
+		if nextToken == "" {
+			return BuildPlaceTree(places), nil
+		}
+	}
+}
+
+// GetPlaceDetail - Place with its master place, children, and votes rolled up from every place below it
+func (handler PlacesHandler) GetPlaceDetail(key store.PlaceKey) (structs.PlaceDetail, error) {
+	place, err := handler.Places.GetPlace(key)
+	if err != nil {
+		return structs.PlaceDetail{}, err
+	}
+
+	detail := structs.PlaceDetail{Place: place, Children: []structs.Place{}}
+	if place.Master != "" {
+		master, err := handler.Places.GetPlace(store.PlaceKey{Abbr: place.Abbr, ID: place.Master})
+		if err == nil {
+			detail.MasterPlace = &master
+		} else if err != store.ErrPlaceNotFound {
+			return structs.PlaceDetail{}, err
+		}
+	}
+
+	descendants, err := handler.GetDescendantPlaces(place.Abbr, place.ID)
+	if err != nil {
+		return structs.PlaceDetail{}, err
+	}
+	for _, descendant := range descendants {
+		if descendant.Master == place.ID {
+			detail.Children = append(detail.Children, descendant)
+		}
+	}
+
+	// The place is the first node, as it is a root or the first place of a cycle
+	detail.TotalVotes = BuildPlaceTree(append([]structs.Place{place}, descendants...))[0].TotalVotes
+	return detail, nil
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/structs"
+)
+
+// treeShape - Each node as "id:total_votes(children)", to compare trees
+func treeShape(nodes []structs.PlaceNode) []string {
+	shape := []string{}
+	for _, node := range nodes {
+		s := node.ID + ":" + strconv.FormatInt(node.TotalVotes, 10)
+		if len(node.Children) > 0 {
+			s += "("
+			for i, child := range treeShape(node.Children) {
+				if i > 0 {
+					s += " "
+				}
+				s += child
+			}
+			s += ")"
+		}
+		shape = append(shape, s)
+	}
+	return shape
+}
+
+func TestBuildPlaceTree(t *testing.T) {
+	place := func(id string, master string, votes int64) structs.Place {
+		return structs.Place{ID: id, Abbr: "SG", Master: master, Votes: votes}
+	}
+
+	tests := []struct {
+		name   string
+		places []structs.Place
+		want   []string
+	}{
+		{"empty", []structs.Place{}, []string{}},
+		{"flat", []structs.Place{place("a", "", 1), place("b", "", 2)}, []string{"a:1", "b:2"}},
+		{"nested", []structs.Place{place("a", "", 1), place("b", "a", 2), place("c", "b", 3), place("d", "a", 0)}, []string{"a:6(b:5(c:3) d:0)"}},
+		{"child listed first", []structs.Place{place("b", "a", 2), place("a", "", 1)}, []string{"a:3(b:2)"}},
+		{"master missing", []structs.Place{place("b", "a", 2), place("c", "b", 1)}, []string{"b:3(c:1)"}},
+		{"own master", []structs.Place{place("a", "a", 1)}, []string{"a:1"}},
+		{"cycle", []structs.Place{place("a", "b", 1), place("b", "a", 2)}, []string{"a:3(b:2)"}},
+		{"cycle below a root", []structs.Place{place("r", "", 1), place("a", "b", 1), place("b", "a", 2)}, []string{"r:1", "a:3(b:2)"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := treeShape(BuildPlaceTree(test.places)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("BuildPlaceTree() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGetDescendantPlaces(t *testing.T) {
+	memory := newTestStore()
+	// A master cycle must not loop forever
+	memory.PutPlace(structs.Place{ID: "sg-9", Abbr: "SG", Master: "sg-10"})
+	memory.PutPlace(structs.Place{ID: "sg-10", Abbr: "SG", Master: "sg-9"})
+
+	tests := []struct {
+		name string
+		id   string
+		want []string
+	}{
+		{"children before grandchildren", "sg-1", []string{"sg-6", "sg-7", "sg-8"}},
+		{"middle", "sg-6", []string{"sg-8"}},
+		{"leaf", "sg-8", []string{}},
+		{"cycle", "sg-9", []string{"sg-10"}},
+	}
+
+	// Capped stores have too many master links to load at once, so each place's children are queried
+	stores := []struct {
+		name   string
+		places store.PlaceStore
+	}{
+		{"", memory},
+		{" capped", cappedMasterStore{memory}},
+	}
+
+	for _, places := range stores {
+		handler := PlacesHandler{Places: places.places}
+		for _, test := range tests {
+			t.Run(test.name+places.name, func(t *testing.T) {
+				descendants, err := handler.GetDescendantPlaces("SG", test.id)
+				if err != nil {
+					t.Fatalf("GetDescendantPlaces() = %v", err)
+				}
+
+				ids := []string{}
+				for _, place := range descendants {
+					ids = append(ids, place.ID)
+				}
+				if !reflect.DeepEqual(ids, test.want) {
+					t.Errorf("GetDescendantPlaces() = %v, want %v", ids, test.want)
+				}
+			})
+		}
+	}
+}
+
+// cappedMasterStore - MemoryStore whose master links always hit the read capacity cap
+type cappedMasterStore struct {
+	*store.MemoryStore
+}
+
+func (capped cappedMasterStore) GetPlacesWithMaster(abbr string) ([]structs.Place, bool, error) {
+	return []structs.Place{}, false, nil
+}
+
+func TestGetPlaceDetailMissingMaster(t *testing.T) {
+	memory := store.NewMemoryStore()
+	memory.PutPlace(structs.Place{ID: "sg-1", Abbr: "SG", Master: "sg-0", Votes: 2})
+
+	detail, err := PlacesHandler{Places: memory}.GetPlaceDetail(store.PlaceKey{Abbr: "SG", ID: "sg-1"})
+	if err != nil {
+		t.Fatalf("GetPlaceDetail() = %v", err)
+	}
+	if detail.MasterPlace != nil || detail.TotalVotes != 2 || len(detail.Children) != 0 {
+		t.Errorf("GetPlaceDetail() = %+v, want no master or children and 2 total votes", detail)
+	}
+}
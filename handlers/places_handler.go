@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+// placeQueryParams - Query string parameters understood by HandleGetPlacesRequest besides PlaceFilters
+var placeQueryParams = []string{"abbr", "limit", "next_token", "long", "lat", "distance", "sort", "tree"}
+
+// placeTreeParams - Query string parameters that can be combined with tree=true
+var placeTreeParams = []string{"abbr", "master", "tree"}
+
+// placeParamRules - Rules of the query string parameters of HandleGetPlacesRequest
+var placeParamRules = utils.Rules{
+	"abbr":     {utils.Required()},
+	"limit":    {utils.IntRange(1, utils.MaxPageLimit)},
+	"long":     {utils.FloatRange(-180, 180), utils.RequiredWith("lat", "distance"), utils.ExcludedWith(store.PlaceFilters...)},
+	"lat":      {utils.FloatRange(-90, 90), utils.RequiredWith("long", "distance"), utils.ExcludedWith(store.PlaceFilters...)},
+	"distance": {utils.FloatRange(0, maxDistanceKm), utils.RequiredWith("long", "lat"), utils.ExcludedWith(store.PlaceFilters...)},
+	// The leaderboard filters on category and zone only, is not a nearby search and has a single page
+	"sort": {utils.OneOf("votes"), utils.ExcludedWith("master", "long", "lat", "distance", "next_token")},
+	"tree": {utils.OneOf("true", "false")},
+}
+
+// Half the Earth's circumference, every place is within this distance
+const maxDistanceKm = 20038
+
+// PlacesHandler - Places lambda, reading from Places
+type PlacesHandler struct {
+	Places store.PlaceStore
+}
+
+// GetPlaces - Get wrapper
+func (handler PlacesHandler) GetPlaces(abbr string, filter string, val string, limit int64, nextToken string) ([]structs.Place, string, error) {
+	if filter == "" {
+		return handler.Places.GetPlaces(abbr, limit, nextToken)
+	}
+	return handler.Places.GetPlacesWithFilter(abbr, filter, val, limit, nextToken)
+}
+
+// GetPlacesResponse - Get response
+func (handler PlacesHandler) GetPlacesResponse(request events.APIGatewayProxyRequest, abbr string, filter string, val string, limit int64, nextToken string) (events.APIGatewayProxyResponse, error) {
+	places, nextToken, err := handler.GetPlaces(abbr, filter, val, limit, nextToken)
+	if err != nil {
+		return utils.GenerateErrorResponse(request, err)
+	}
+
+	return utils.GenerateResponse(request, places, nextToken)
+}
+
+// GetTopVotedPlacesResponse - Get response
+func (handler PlacesHandler) GetTopVotedPlacesResponse(request events.APIGatewayProxyRequest, abbr string, category string, zone string, limit int64) (events.APIGatewayProxyResponse, error) {
+	places, err := handler.Places.GetTopVotedPlaces(abbr, category, zone, limit)
+	if err != nil {
+		return utils.GenerateErrorResponse(request, err)
+	}
+
+	return utils.GenerateResponse(request, places, "")
+}
+
+// GetPlacesByLongLatResponse - Get response
+func (handler PlacesHandler) GetPlacesByLongLatResponse(request events.APIGatewayProxyRequest, abbr string, long float64, lat float64, distance float64, limit int64, nextToken string) (events.APIGatewayProxyResponse, error) {
+	places, nextToken, err := handler.Places.GetPlacesByLongLat(abbr, long, lat, distance, limit, nextToken)
+	if err != nil {
+		return utils.GenerateErrorResponse(request, err)
+	}
+
+	return utils.GenerateResponse(request, places, nextToken)
+}
+
+// GetPlaceTreeResponse - Get response
+func (handler PlacesHandler) GetPlaceTreeResponse(request events.APIGatewayProxyRequest, abbr string, master string) (events.APIGatewayProxyResponse, error) {
+	tree, err := handler.GetPlaceTree(abbr, master)
+	if err != nil {
+		return utils.GenerateErrorResponse(request, err)
+	}
+
+	return utils.GenerateResponse(request, tree, "")
+}
+
+// GetPlaceDetailResponse - Get response
+func (handler PlacesHandler) GetPlaceDetailResponse(request events.APIGatewayProxyRequest, key store.PlaceKey) (events.APIGatewayProxyResponse, error) {
+	detail, err := handler.GetPlaceDetail(key)
+	if err != nil {
+		return utils.GenerateErrorResponse(request, err)
+	}
+
+	return utils.GenerateResponse(request, detail, "")
+}
+
+// HandleGetPlacesRequest - Lambda function, GET /places lists places and GET /places/{abbr}/{id} gets one
+func (handler PlacesHandler) HandleGetPlacesRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	if request.HTTPMethod == "GET" {
+		if id, ok := request.PathParameters["id"]; ok {
+			key := store.PlaceKey{Abbr: request.PathParameters["abbr"], ID: id}
+			logger.Info("Get place", logging.Fields{"abbr": key.Abbr, "place_id": key.ID})
+			return handler.GetPlaceDetailResponse(request, key)
+		}
+
+		for param := range request.QueryStringParameters {
+			if !utils.ContainsString(placeQueryParams, param) && !utils.ContainsString(store.PlaceFilters, param) {
+				logger.Info("Unsupported filter", logging.Fields{"filter": param})
+				return utils.GenerateErrorResponse(request, store.ErrUnsupportedFilter)
+			}
+		}
+
+		err := placeParamRules.Validate(request.QueryStringParameters)
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		}
+		queryLimit := utils.IntParam(request.QueryStringParameters, "limit", 50)
+		nextToken := request.QueryStringParameters["next_token"]
+
+		abbr := request.QueryStringParameters["abbr"]
+		if request.QueryStringParameters["tree"] == "true" {
+			for param := range request.QueryStringParameters {
+				if !utils.ContainsString(placeTreeParams, param) {
+					return utils.GenerateErrorResponse(request, utils.NewFieldValidationError([]utils.FieldError{
+						{Field: "tree", Message: "can only be combined with abbr and master"},
+					}))
+				}
+			}
+
+			master := request.QueryStringParameters["master"]
+			logger.Info("Get place tree", logging.Fields{"abbr": abbr, "master": master})
+			return handler.GetPlaceTreeResponse(request, abbr, master)
+		}
+
+		if _, ok := request.QueryStringParameters["sort"]; ok {
+			category := request.QueryStringParameters["category"]
+			zone := request.QueryStringParameters["zone"]
+			logger.Info("Get top voted places", logging.Fields{"abbr": abbr, "category": category, "zone": zone})
+			return handler.GetTopVotedPlacesResponse(request, abbr, category, zone, queryLimit)
+		}
+
+		long, longOk := request.QueryStringParameters["long"]
+		lat, latOk := request.QueryStringParameters["lat"]
+		distance, distanceOk := request.QueryStringParameters["distance"]
+
+		if longOk && latOk && distanceOk {
+			// Validated by placeParamRules
+			longF, _ := strconv.ParseFloat(long, 64)
+			latF, _ := strconv.ParseFloat(lat, 64)
+			distanceF, _ := strconv.ParseFloat(distance, 64)
+
+			logger.Info("Get places by long lat", logging.Fields{"abbr": abbr, "long": longF, "lat": latF, "distance": distanceF})
+			return handler.GetPlacesByLongLatResponse(request, abbr, longF, latF, distanceF, queryLimit, nextToken)
+		}
+
+		filters := []string{}
+		for _, filter := range store.PlaceFilters {
+			if _, ok := request.QueryStringParameters[filter]; ok {
+				filters = append(filters, filter)
+			}
+		}
+
+		if len(filters) > 1 {
+			return utils.GenerateErrorResponse(request, utils.NewValidationError(utils.ErrorCodeUnsupportedFilter, "Only one filter is supported at a time, got: "+strings.Join(filters, ", ")))
+		} else if len(filters) == 1 {
+			val := request.QueryStringParameters[filters[0]]
+			logger.Info("Get places with filter", logging.Fields{"abbr": abbr, "filter": filters[0], "value": val})
+			return handler.GetPlacesResponse(request, abbr, filters[0], val, queryLimit, nextToken)
+		} else {
+			logger.Info("Get places", logging.Fields{"abbr": abbr})
+			return handler.GetPlacesResponse(request, abbr, "", "", queryLimit, nextToken)
+		}
+	} else {
+		return utils.GenerateErrorResponse(request, utils.NewMethodNotAllowedError(request.HTTPMethod, "GET"))
+	}
+}
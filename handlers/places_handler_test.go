@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/utils"
+)
+
+func TestHandleGetPlacesRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  map[string]string
+		ids     []string
+		hasNext bool
+	}{
+		{"country", map[string]string{"abbr": "SG"}, []string{"sg-1", "sg-2", "sg-3", "sg-4", "sg-5", "sg-6", "sg-7", "sg-8"}, false},
+		{"first page", map[string]string{"abbr": "SG", "limit": "3"}, []string{"sg-1", "sg-2", "sg-3"}, true},
+		{"unknown country", map[string]string{"abbr": "XX"}, []string{}, false},
+		{"category", map[string]string{"abbr": "SG", "category": "Nature"}, []string{"sg-1", "sg-3", "sg-6", "sg-7", "sg-8"}, false},
+		{"zone", map[string]string{"abbr": "SG", "zone": "South"}, []string{"sg-3"}, false},
+		{"master", map[string]string{"abbr": "SG", "master": "sg-1"}, []string{"sg-6", "sg-7"}, false},
+		{"top voted", map[string]string{"abbr": "SG", "sort": "votes"}, []string{"sg-2", "sg-1", "sg-6", "sg-3", "sg-8"}, false},
+		{"top voted limit", map[string]string{"abbr": "SG", "sort": "votes", "limit": "2"}, []string{"sg-2", "sg-1"}, false},
+		{"top voted category", map[string]string{"abbr": "SG", "sort": "votes", "category": "Nature"}, []string{"sg-1", "sg-6", "sg-3", "sg-8"}, false},
+		{"top voted zone", map[string]string{"abbr": "SG", "sort": "votes", "zone": "Central", "category": "Nature"}, []string{"sg-1", "sg-6", "sg-8"}, false},
+		{"nearby", map[string]string{"abbr": "SG", "lat": "1.2816", "long": "103.8636", "distance": "0.4"}, []string{"sg-1", "sg-7", "sg-6", "sg-8", "sg-2"}, false},
+		{"nearby page", map[string]string{"abbr": "SG", "lat": "1.2816", "long": "103.8636", "distance": "0.4", "limit": "2"}, []string{"sg-1", "sg-7"}, true},
+	}
+
+	handler := PlacesHandler{Places: newTestStore()}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, _ := handler.HandleGetPlacesRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: test.params})
+			envelope := decodeResponse(t, response, http.StatusOK, "")
+
+			if ids := responseIDs(t, envelope); !reflect.DeepEqual(ids, test.ids) {
+				t.Errorf("HandleGetPlacesRequest() places = %v, want %v", ids, test.ids)
+			}
+			if (envelope.NextToken != "") != test.hasNext {
+				t.Errorf("HandleGetPlacesRequest() next_token = %q, want one %v", envelope.NextToken, test.hasNext)
+			}
+		})
+	}
+}
+
+func TestHandleGetPlacesRequestPages(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		ids    []string
+	}{
+		{"country", map[string]string{"abbr": "SG", "limit": "3"}, []string{"sg-1", "sg-2", "sg-3", "sg-4", "sg-5", "sg-6", "sg-7", "sg-8"}},
+		{"category", map[string]string{"abbr": "SG", "category": "Nature", "limit": "2"}, []string{"sg-1", "sg-3", "sg-6", "sg-7", "sg-8"}},
+		{"nearby", map[string]string{"abbr": "SG", "lat": "1.2816", "long": "103.8636", "distance": "0.4", "limit": "2"}, []string{"sg-1", "sg-7", "sg-6", "sg-8", "sg-2"}},
+	}
+
+	handler := PlacesHandler{Places: newTestStore()}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ids := []string{}
+			params := test.params
+			for pages := 0; ; pages++ {
+				if pages > len(test.ids) {
+					t.Fatalf("HandleGetPlacesRequest() did not finish after %d pages", pages)
+				}
+
+				response, _ := handler.HandleGetPlacesRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: params})
+				envelope := decodeResponse(t, response, http.StatusOK, "")
+				ids = append(ids, responseIDs(t, envelope)...)
+				if envelope.NextToken == "" {
+					break
+				}
+
+				params = map[string]string{"next_token": envelope.NextToken}
+				for name, value := range test.params {
+					params[name] = value
+				}
+			}
+
+			if !reflect.DeepEqual(ids, test.ids) {
+				t.Errorf("HandleGetPlacesRequest() pages = %v, want %v", ids, test.ids)
+			}
+		})
+	}
+}
+
+func TestHandleGetPlacesRequestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		params map[string]string
+		status int
+		code   utils.ErrorCode
+		fields []string
+	}{
+		{"no abbr", "GET", map[string]string{}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"abbr"}},
+		{"limit too small", "GET", map[string]string{"abbr": "SG", "limit": "0"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"limit"}},
+		{"limit too large", "GET", map[string]string{"abbr": "SG", "limit": "201"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"limit"}},
+		{"limit not a number", "GET", map[string]string{"abbr": "SG", "limit": "ten"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"limit"}},
+		{"latitude out of range", "GET", map[string]string{"abbr": "SG", "lat": "91", "long": "0", "distance": "1"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"lat"}},
+		{"negative distance", "GET", map[string]string{"abbr": "SG", "lat": "1", "long": "103", "distance": "-1"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"distance"}},
+		{"latitude alone", "GET", map[string]string{"abbr": "SG", "lat": "1"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"lat"}},
+		{"no distance", "GET", map[string]string{"abbr": "SG", "lat": "1", "long": "103"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"lat", "long"}},
+		{"nearby with a filter", "GET", map[string]string{"abbr": "SG", "lat": "1", "long": "103", "distance": "1", "category": "Nature"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"distance", "lat", "long"}},
+		{"sort with master", "GET", map[string]string{"abbr": "SG", "sort": "votes", "master": "sg-1"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"sort"}},
+		{"sort with nearby", "GET", map[string]string{"abbr": "SG", "sort": "votes", "lat": "1", "long": "103", "distance": "1"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"sort"}},
+		{"sort with next_token", "GET", map[string]string{"abbr": "SG", "sort": "votes", "next_token": "abc"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"sort"}},
+		{"unknown sort", "GET", map[string]string{"abbr": "SG", "sort": "name"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"sort"}},
+		{"tree with limit", "GET", map[string]string{"abbr": "SG", "tree": "true", "limit": "5"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"tree"}},
+		{"unknown tree", "GET", map[string]string{"abbr": "SG", "tree": "yes"}, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, []string{"tree"}},
+		{"unknown parameter", "GET", map[string]string{"abbr": "SG", "name": "Sentosa"}, http.StatusBadRequest, utils.ErrorCodeUnsupportedFilter, nil},
+		{"two filters", "GET", map[string]string{"abbr": "SG", "category": "Nature", "zone": "South"}, http.StatusBadRequest, utils.ErrorCodeUnsupportedFilter, nil},
+		{"bad next_token", "GET", map[string]string{"abbr": "SG", "next_token": "not-a-token"}, http.StatusBadRequest, utils.ErrorCodeInvalidNextToken, nil},
+		{"POST", "POST", map[string]string{"abbr": "SG"}, http.StatusMethodNotAllowed, utils.ErrorCodeMethodNotAllowed, nil},
+	}
+
+	handler := PlacesHandler{Places: newTestStore()}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := handler.HandleGetPlacesRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: test.method, QueryStringParameters: test.params})
+			if err == nil {
+				t.Errorf("HandleGetPlacesRequest() error = nil, want the error to log")
+			}
+			envelope := decodeResponse(t, response, test.status, test.code)
+
+			fields := []string{}
+			for _, field := range envelope.Error.Fields {
+				fields = append(fields, field.Field)
+			}
+			if test.fields != nil && !reflect.DeepEqual(fields, test.fields) {
+				t.Errorf("HandleGetPlacesRequest() fields = %v, want %v", fields, test.fields)
+			}
+		})
+	}
+}
+
+func TestHandleGetPlacesRequestMethodNotAllowed(t *testing.T) {
+	handler := PlacesHandler{Places: newTestStore()}
+	response, _ := handler.HandleGetPlacesRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "DELETE"})
+	decodeResponse(t, response, http.StatusMethodNotAllowed, utils.ErrorCodeMethodNotAllowed)
+
+	if allow := response.Headers["Allow"]; allow != "GET" {
+		t.Errorf("HandleGetPlacesRequest() Allow = %q, want GET", allow)
+	}
+}
+
+func TestHandleGetPlacesRequestDetail(t *testing.T) {
+	tests := []struct {
+		name       string
+		abbr, id   string
+		status     int
+		code       utils.ErrorCode
+		totalVotes int64
+		master     string
+		children   []string
+	}{
+		{"master of a subtree", "SG", "sg-1", http.StatusOK, "", 6, "", []string{"sg-6", "sg-7"}},
+		{"middle of a subtree", "SG", "sg-6", http.StatusOK, "", 3, "sg-1", []string{"sg-8"}},
+		{"leaf", "SG", "sg-8", http.StatusOK, "", 1, "sg-6", []string{}},
+		{"place of another country", "MY", "sg-1", http.StatusNotFound, utils.ErrorCodePlaceNotFound, 0, "", nil},
+		{"unknown place", "SG", "sg-9", http.StatusNotFound, utils.ErrorCodePlaceNotFound, 0, "", nil},
+	}
+
+	handler := PlacesHandler{Places: newTestStore()}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, _ := handler.HandleGetPlacesRequest(context.Background(), events.APIGatewayProxyRequest{
+				HTTPMethod:     "GET",
+				PathParameters: map[string]string{"abbr": test.abbr, "id": test.id},
+			})
+			envelope := decodeResponse(t, response, test.status, test.code)
+			if test.code != "" {
+				return
+			}
+
+			detail := struct {
+				ID          string `json:"id"`
+				TotalVotes  int64  `json:"total_votes"`
+				MasterPlace *struct {
+					ID string `json:"id"`
+				} `json:"master_place"`
+				Children json.RawMessage `json:"children"`
+			}{}
+			err := json.Unmarshal(envelope.Data, &detail)
+			if err != nil {
+				t.Fatalf("Decoding detail %s failed: %v", envelope.Data, err)
+			}
+
+			if detail.ID != test.id || detail.TotalVotes != test.totalVotes {
+				t.Errorf("HandleGetPlacesRequest() = %s with total votes %d, want %s with %d", detail.ID, detail.TotalVotes, test.id, test.totalVotes)
+			}
+			master := ""
+			if detail.MasterPlace != nil {
+				master = detail.MasterPlace.ID
+			}
+			if master != test.master {
+				t.Errorf("HandleGetPlacesRequest() master_place = %q, want %q", master, test.master)
+			}
+			if children := responseIDs(t, testEnvelope{Data: detail.Children}); !reflect.DeepEqual(children, test.children) {
+				t.Errorf("HandleGetPlacesRequest() children = %v, want %v", children, test.children)
+			}
+		})
+	}
+}
+
+func TestHandleGetPlacesRequestTree(t *testing.T) {
+	type node struct {
+		ID         string `json:"id"`
+		TotalVotes int64  `json:"total_votes"`
+		Children   []node `json:"children"`
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]string
+		roots  []string
+		votes  map[string]int64
+	}{
+		{"country", map[string]string{"abbr": "SG", "tree": "true"}, []string{"sg-1", "sg-2", "sg-3", "sg-4", "sg-5"}, map[string]int64{"sg-1": 6, "sg-6": 3, "sg-8": 1, "sg-2": 5}},
+		{"below master", map[string]string{"abbr": "SG", "tree": "true", "master": "sg-1"}, []string{"sg-6", "sg-7"}, map[string]int64{"sg-6": 3, "sg-7": 0, "sg-8": 1}},
+		{"below a leaf", map[string]string{"abbr": "SG", "tree": "true", "master": "sg-8"}, []string{}, map[string]int64{}},
+	}
+
+	handler := PlacesHandler{Places: newTestStore()}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, _ := handler.HandleGetPlacesRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "GET", QueryStringParameters: test.params})
+			envelope := decodeResponse(t, response, http.StatusOK, "")
+
+			tree := []node{}
+			err := json.Unmarshal(envelope.Data, &tree)
+			if err != nil {
+				t.Fatalf("Decoding tree %s failed: %v", envelope.Data, err)
+			}
+
+			roots := []string{}
+			votes := map[string]int64{}
+			var walk func(nodes []node)
+			walk = func(nodes []node) {
+				for _, n := range nodes {
+					votes[n.ID] = n.TotalVotes
+					walk(n.Children)
+				}
+			}
+			for _, root := range tree {
+				roots = append(roots, root.ID)
+			}
+			walk(tree)
+
+			if !reflect.DeepEqual(roots, test.roots) {
+				t.Errorf("HandleGetPlacesRequest() roots = %v, want %v", roots, test.roots)
+			}
+			for id, want := range test.votes {
+				if votes[id] != want {
+					t.Errorf("HandleGetPlacesRequest() total votes of %s = %d, want %d", id, votes[id], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/utils"
+)
+
+// tokenErrorCodes - Error code of each rejected TokenStatus
+var tokenErrorCodes = map[identity.TokenStatus]utils.ErrorCode{
+	identity.TokenInvalid:       utils.ErrorCodeInvalidToken,
+	identity.TokenWrongApp:      utils.ErrorCodeTokenWrongApp,
+	identity.TokenExpired:       utils.ErrorCodeTokenExpired,
+	identity.TokenMissingScopes: utils.ErrorCodeTokenMissingScopes,
+}
+
+// tokenError - Error of a rejected token, an upstream error if it could not be checked
+func tokenError(status identity.TokenStatus) error {
+	if status == identity.TokenUpstreamFailure {
+		return utils.NewUpstreamError(status.String(), nil)
+	}
+	return utils.NewAuthError(tokenErrorCodes[status], status.String())
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/session"
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/utils"
+)
+
+// VoteAPIParams - Caps for field names, because of json.Marshal requirements
+type VoteAPIParams struct {
+	identity.IdentityParams
+	PlaceID   string `json:"place_id"`
+	PlaceAbbr string `json:"place_abbr"`
+}
+
+// VoteResult - Caps for field names, because of json.Marshal requirements
+type VoteResult struct {
+	PlaceID   string `json:"place_id"`
+	PlaceAbbr string `json:"place_abbr"`
+	Voted     bool   `json:"voted"`
+}
+
+// voteParamRules - Rules of the query string parameters of a vote history request
+var voteParamRules = utils.Rules{
+	"limit": {utils.IntRange(1, maxVoteHistoryLimit)},
+
+	// Credentials used to be accepted here, tell those clients to move them to headers
+	"provider":        {utils.Rejected(credentialsInQueryMessage)},
+	"user_id":         {utils.Rejected(credentialsInQueryMessage)},
+	"token":           {utils.Rejected(credentialsInQueryMessage)},
+	"fb_id":           {utils.Rejected(credentialsInQueryMessage)},
+	"fb_access_token": {utils.Rejected(credentialsInQueryMessage)},
+}
+
+const credentialsInQueryMessage = "must not be in the query string, send Authorization: Bearer or the " + identity.ProviderHeader + ", " + identity.UserIDHeader + " and " + identity.TokenHeader + " headers"
+
+// voteBodyRules - Rules of the VoteAPIParams body of a vote or unvote request, credentials are checked by Authenticate
+var voteBodyRules = utils.Rules{
+	"place_id":   {utils.Required(), utils.MaxLength(maxPlaceKeyLength)},
+	"place_abbr": {utils.Required(), utils.MaxLength(maxPlaceKeyLength)},
+}
+
+// Longest place_id and place_abbr accepted, far above any key in Places
+const maxPlaceKeyLength = 64
+
+// VotesHandler - Vote lambda, writing to Votes and reading voted places from Places.
+// Users are authenticated with a session from Sessions, or directly with Identities.
+type VotesHandler struct {
+	Votes      store.VoteStore
+	Places     store.PlaceStore
+	Identities identity.IdentityVerifiers
+	Sessions   *session.SessionSigner
+}
+
+// Authenticate - Voter making the request, from an "Authorization: Bearer" session token verified locally,
+// else from the identity provider's token in params
+func (handler VotesHandler) Authenticate(headers map[string]string, params identity.IdentityParams) (string, identity.TokenStatus, error) {
+	if token, ok := session.BearerToken(headers); ok {
+		voterID, status := handler.Sessions.VerifySessionToken(token)
+		return voterID, status, nil
+	}
+
+	err := params.Validate()
+	if err != nil {
+		return "", identity.TokenInvalid, err
+	}
+
+	provider, userID, token := params.Credentials()
+	status, ok := handler.Identities.Verify(provider, userID, token)
+	if !ok {
+		return "", status, utils.NewValidationError(utils.ErrorCodeUnsupportedProvider, "Unsupported provider: "+provider)
+	}
+	return identity.VoterID(provider, userID), status, nil
+}
+
+// HandleVotePlaceRequest - Lambda function
+func (handler VotesHandler) HandleVotePlaceRequest(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	logger := logging.FromContext(ctx)
+	if request.HTTPMethod == "GET" {
+		err := voteParamRules.Validate(request.QueryStringParameters)
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		}
+		queryLimit := utils.IntParam(request.QueryStringParameters, "limit", 50)
+
+		// Without a session, the provider credentials are in headers
+		params := identity.IdentityParams{}
+		if _, ok := session.BearerToken(request.Headers); !ok {
+			params, err = identity.IdentityParamsFromHeaders(request.Headers)
+			if err != nil {
+				return utils.GenerateErrorResponse(request, err)
+			}
+		}
+
+		voterID, status, err := handler.Authenticate(request.Headers, params)
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		} else if status != identity.TokenValid {
+			logger.Warn("Token rejected", logging.Fields{"voter_id": voterID, "status": status.String()})
+			return utils.GenerateErrorResponse(request, tokenError(status))
+		}
+
+		logger.Info("Get vote history", logging.Fields{"voter_id": voterID})
+		history, nextToken, err := handler.GetVoteHistory(voterID, queryLimit, request.QueryStringParameters["next_token"])
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		}
+
+		return utils.GenerateResponse(request, history, nextToken)
+	} else if request.HTTPMethod == "POST" || request.HTTPMethod == "DELETE" {
+		params := VoteAPIParams{}
+		err := voteBodyRules.DecodeBody(request.Body, &params)
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		}
+
+		voterID, status, err := handler.Authenticate(request.Headers, params.IdentityParams)
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		} else if status != identity.TokenValid {
+			logger.Warn("Token rejected", logging.Fields{"voter_id": voterID, "status": status.String()})
+			return utils.GenerateErrorResponse(request, tokenError(status))
+		}
+
+		if request.HTTPMethod == "POST" {
+			logger.Info("Vote place", logging.Fields{"abbr": params.PlaceAbbr, "place_id": params.PlaceID, "voter_id": voterID})
+			err = handler.Votes.Vote(voterID, store.PlaceKey{Abbr: params.PlaceAbbr, ID: params.PlaceID})
+		} else {
+			logger.Info("Unvote place", logging.Fields{"abbr": params.PlaceAbbr, "place_id": params.PlaceID, "voter_id": voterID})
+			err = handler.Votes.Unvote(voterID, store.PlaceKey{Abbr: params.PlaceAbbr, ID: params.PlaceID})
+		}
+
+		if err != nil {
+			return utils.GenerateErrorResponse(request, err)
+		}
+
+		return utils.GenerateResponse(request, VoteResult{PlaceID: params.PlaceID, PlaceAbbr: params.PlaceAbbr, Voted: request.HTTPMethod == "POST"}, "")
+	} else {
+		return utils.GenerateErrorResponse(request, utils.NewMethodNotAllowedError(request.HTTPMethod, "GET", "POST", "DELETE"))
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/store"
+	"github.com/shikang/travote-be/utils"
+)
+
+func TestHandleVotePlaceRequest(t *testing.T) {
+	signer := newTestSessionSigner()
+	issued, err := signer.IssueSession(identity.VoterID(identity.ProviderGoogle, "1"))
+	if err != nil {
+		t.Fatalf("IssueSession() = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		body    string
+		voted   bool // The voter has voted for sg-1 before the request
+		status  int
+		code    utils.ErrorCode
+		votes   int64 // Votes of sg-1 after the request
+	}{
+		{"vote", "POST", nil, `{"provider":"google","user_id":"1","token":"valid","place_id":"sg-1","place_abbr":"SG"}`, false, http.StatusOK, "", 4},
+		{"vote with a session", "POST", map[string]string{"Authorization": "Bearer " + issued.AccessToken}, `{"place_id":"sg-1","place_abbr":"SG"}`, false, http.StatusOK, "", 4},
+		{"vote twice", "POST", nil, `{"provider":"google","user_id":"1","token":"valid","place_id":"sg-1","place_abbr":"SG"}`, true, http.StatusConflict, utils.ErrorCodeAlreadyVoted, 4},
+		{"unvote", "DELETE", nil, `{"provider":"google","user_id":"1","token":"valid","place_id":"sg-1","place_abbr":"SG"}`, true, http.StatusOK, "", 3},
+		{"unvote without a vote", "DELETE", nil, `{"provider":"google","user_id":"1","token":"valid","place_id":"sg-1","place_abbr":"SG"}`, false, http.StatusNotFound, utils.ErrorCodeVoteNotFound, 3},
+		{"inactive place", "POST", nil, `{"provider":"google","user_id":"1","token":"valid","place_id":"sg-5","place_abbr":"SG"}`, false, http.StatusConflict, utils.ErrorCodePlaceInactive, 3},
+		{"place of another country", "POST", nil, `{"provider":"google","user_id":"1","token":"valid","place_id":"sg-1","place_abbr":"MY"}`, false, http.StatusBadRequest, utils.ErrorCodePlaceAbbrMismatch, 3},
+		{"unknown place", "POST", nil, `{"provider":"google","user_id":"1","token":"valid","place_id":"sg-9","place_abbr":"SG"}`, false, http.StatusNotFound, utils.ErrorCodePlaceNotFound, 3},
+		{"no place", "POST", nil, `{"provider":"google","user_id":"1","token":"valid","place_abbr":"SG"}`, false, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, 3},
+		{"place id too long", "POST", nil, `{"provider":"google","user_id":"1","token":"valid","place_id":"` + strings.Repeat("x", 65) + `","place_abbr":"SG"}`, false, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, 3},
+		{"no token", "POST", nil, `{"provider":"google","user_id":"1","place_id":"sg-1","place_abbr":"SG"}`, false, http.StatusBadRequest, utils.ErrorCodeInvalidParameter, 3},
+		{"not JSON", "POST", nil, `place_id=sg-1`, false, http.StatusBadRequest, utils.ErrorCodeBadRequest, 3},
+		{"unsupported provider", "POST", nil, `{"provider":"apple","user_id":"1","token":"valid","place_id":"sg-1","place_abbr":"SG"}`, false, http.StatusBadRequest, utils.ErrorCodeUnsupportedProvider, 3},
+		{"expired token", "POST", nil, `{"provider":"google","user_id":"1","token":"expired","place_id":"sg-1","place_abbr":"SG"}`, false, http.StatusUnauthorized, utils.ErrorCodeTokenExpired, 3},
+		{"token of another app", "POST", nil, `{"provider":"google","user_id":"1","token":"wrong_app","place_id":"sg-1","place_abbr":"SG"}`, false, http.StatusUnauthorized, utils.ErrorCodeTokenWrongApp, 3},
+		{"invalid session", "POST", map[string]string{"Authorization": "Bearer " + issued.RefreshToken}, `{"place_id":"sg-1","place_abbr":"SG"}`, false, http.StatusUnauthorized, utils.ErrorCodeInvalidToken, 3},
+		{"PUT", "PUT", nil, `{}`, false, http.StatusMethodNotAllowed, utils.ErrorCodeMethodNotAllowed, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, memory := newTestVotesHandler()
+			voterID := identity.VoterID(identity.ProviderGoogle, "1")
+			if test.voted {
+				err := memory.Vote(voterID, store.PlaceKey{Abbr: "SG", ID: "sg-1"})
+				if err != nil {
+					t.Fatalf("Vote() = %v", err)
+				}
+			}
+
+			response, _ := handler.HandleVotePlaceRequest(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: test.method, Headers: test.headers, Body: test.body})
+			envelope := decodeResponse(t, response, test.status, test.code)
+
+			if test.code == "" {
+				result := VoteResult{}
+				err := json.Unmarshal(envelope.Data, &result)
+				if err != nil || result != (VoteResult{PlaceID: "sg-1", PlaceAbbr: "SG", Voted: test.method == "POST"}) {
+					t.Errorf("HandleVotePlaceRequest() = %s, want sg-1 voted %v", envelope.Data, test.method == "POST")
+				}
+
+				votes, _, err := memory.GetUserVotes(voterID, 10, "")
+				if err != nil || (len(votes) == 1) != (test.method == "POST") {
+					t.Errorf("GetUserVotes() = %v, %v after %s", votes, err, test.method)
+				}
+			}
+
+			place, err := memory.GetPlace(store.PlaceKey{Abbr: "SG", ID: "sg-1"})
+			if err != nil || place.Votes != test.votes {
+				t.Errorf("Votes of sg-1 = %d, %v, want %d", place.Votes, err, test.votes)
+			}
+		})
+	}
+}
+
+// Clients that predate provider send fb_id and fb_access_token, and vote as the Facebook user
+func TestHandleVotePlaceRequestLegacyCredentials(t *testing.T) {
+	handler, memory := newTestVotesHandler()
+	response, _ := handler.HandleVotePlaceRequest(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Body:       `{"fb_id":"1","fb_access_token":"valid","place_id":"sg-1","place_abbr":"SG"}`,
+	})
+	decodeResponse(t, response, http.StatusOK, "")
+
+	votes, _, err := memory.GetUserVotes(identity.VoterID(identity.ProviderFacebook, "1"), 10, "")
+	if err != nil || len(votes) != 1 || votes[0].PlaceID != "sg-1" {
+		t.Errorf("GetUserVotes() = %v, %v, want the vote for sg-1", votes, err)
+	}
+}
@@ -0,0 +1,149 @@
+package identity
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/shikang/travote-be/utils"
+)
+
+// TokenStatus - Outcome of verifying an access token
+type TokenStatus int
+
+const (
+	// TokenValid - Token was issued to the user for this app
+	TokenValid TokenStatus = iota
+	// TokenInvalid - Token is malformed, revoked or was issued to another user
+	TokenInvalid
+	// TokenWrongApp - Token was issued for another app
+	TokenWrongApp
+	// TokenExpired - Token has expired
+	TokenExpired
+	// TokenMissingScopes - Token lacks permissions the app requires
+	TokenMissingScopes
+	// TokenUpstreamFailure - Token could not be checked with the identity provider
+	TokenUpstreamFailure
+)
+
+func (status TokenStatus) String() string {
+	switch status {
+	case TokenValid:
+		return "Valid token"
+	case TokenInvalid:
+		return "Invalid token"
+	case TokenWrongApp:
+		return "Token issued for another app"
+	case TokenExpired:
+		return "Token expired"
+	case TokenMissingScopes:
+		return "Token missing required permissions"
+	default:
+		return "Token verification unavailable"
+	}
+}
+
+// TokenVerifier - Checks that an access token was issued to the user for this app
+type TokenVerifier interface {
+	VerifyAccessToken(userID string, accessToken string) TokenStatus
+}
+
+// Identity providers users can sign in with
+const (
+	ProviderFacebook = "facebook"
+	ProviderGoogle   = "google"
+	ProviderApple    = "apple"
+)
+
+// IdentityVerifiers - TokenVerifier of each enabled identity provider
+type IdentityVerifiers map[string]TokenVerifier
+
+// Verify - Check that token was issued to the provider's user userID
+func (verifiers IdentityVerifiers) Verify(provider string, userID string, token string) (TokenStatus, bool) {
+	verifier, ok := verifiers[provider]
+	if !ok {
+		return TokenInvalid, false
+	}
+	return verifier.VerifyAccessToken(userID, token), true
+}
+
+// Providers - Names of the enabled identity providers
+func (verifiers IdentityVerifiers) Providers() []string {
+	providers := []string{}
+	for provider := range verifiers {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// IdentityParams - Caps for field names, because of json.Marshal requirements.
+// fb_id and fb_access_token are still accepted from clients that predate provider.
+type IdentityParams struct {
+	Provider            string `json:"provider"`
+	UserID              string `json:"user_id"`
+	Token               string `json:"token"`
+	FacebookUserID      string `json:"fb_id"`
+	FacebookAccessToken string `json:"fb_access_token"`
+}
+
+// Headers of the identity provider credentials of GET requests, which have no body.
+// Query strings are kept in access logs and browser history, so tokens are never read from them.
+const (
+	ProviderHeader = "X-Identity-Provider"
+	UserIDHeader   = "X-Identity-User-Id"
+	TokenHeader    = "X-Identity-Token"
+)
+
+// headerCredentialRules - Rules of the credential headers
+var headerCredentialRules = utils.Rules{UserIDHeader: {utils.Required()}, TokenHeader: {utils.Required()}}
+
+// IdentityParamsFromHeaders - IdentityParams of a GET request, 400 error if the user ID or token header is missing.
+// API Gateway keeps the headers' case.
+func IdentityParamsFromHeaders(headers map[string]string) (IdentityParams, error) {
+	values := map[string]string{}
+	for name, value := range headers {
+		for _, header := range []string{ProviderHeader, UserIDHeader, TokenHeader} {
+			if strings.EqualFold(name, header) {
+				values[header] = value
+			}
+		}
+	}
+
+	err := headerCredentialRules.Validate(values)
+	if err != nil {
+		return IdentityParams{}, err
+	}
+	return IdentityParams{Provider: values[ProviderHeader], UserID: values[UserIDHeader], Token: values[TokenHeader]}, nil
+}
+
+// Credentials - Provider, user and token, falling back to the legacy Facebook fields
+func (params IdentityParams) Credentials() (string, string, string) {
+	if params.Provider == "" && params.UserID == "" && params.Token == "" {
+		return ProviderFacebook, params.FacebookUserID, params.FacebookAccessToken
+	}
+
+	provider := params.Provider
+	if provider == "" {
+		provider = ProviderFacebook
+	}
+	return provider, params.UserID, params.Token
+}
+
+// credentialRules - Rules of the credentials of IdentityParams, and of its legacy Facebook fields
+var (
+	credentialRules       = utils.Rules{"user_id": {utils.Required()}, "token": {utils.Required()}}
+	legacyCredentialRules = utils.Rules{"fb_id": {utils.Required()}, "fb_access_token": {utils.Required()}}
+)
+
+// Validate - 400 error if the user ID or token is missing, from the legacy fields if only those are set
+func (params IdentityParams) Validate() error {
+	if params.Provider == "" && params.UserID == "" && params.Token == "" && (params.FacebookUserID != "" || params.FacebookAccessToken != "") {
+		return legacyCredentialRules.Validate(map[string]string{"fb_id": params.FacebookUserID, "fb_access_token": params.FacebookAccessToken})
+	}
+	return credentialRules.Validate(map[string]string{"user_id": params.UserID, "token": params.Token})
+}
+
+// VoterID - Key of a user across identity providers, as subjects are only unique within a provider
+func VoterID(provider string, userID string) string {
+	return provider + "#" + userID
+}
@@ -0,0 +1,64 @@
+package identity
+
+import (
+	"testing"
+
+	"github.com/shikang/travote-be/utils"
+)
+
+func TestIdentityParamsFromHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    IdentityParams
+		wantErr bool
+	}{
+		{"every header", map[string]string{ProviderHeader: "google", UserIDHeader: "123", TokenHeader: "abc"}, IdentityParams{Provider: "google", UserID: "123", Token: "abc"}, false},
+		{"lower case", map[string]string{"x-identity-user-id": "123", "x-identity-token": "abc"}, IdentityParams{UserID: "123", Token: "abc"}, false},
+		{"no token", map[string]string{UserIDHeader: "123"}, IdentityParams{}, true},
+		{"no headers", map[string]string{}, IdentityParams{}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := IdentityParamsFromHeaders(test.headers)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("IdentityParamsFromHeaders() = %v, want error %v", err, test.wantErr)
+			}
+			if err != nil && utils.AsAPIError(err).Code != utils.ErrorCodeInvalidParameter {
+				t.Errorf("IdentityParamsFromHeaders() code = %v, want %v", utils.AsAPIError(err).Code, utils.ErrorCodeInvalidParameter)
+			}
+			if got != test.want {
+				t.Errorf("IdentityParamsFromHeaders() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIdentityParamsCredentials(t *testing.T) {
+	tests := []struct {
+		name                    string
+		params                  IdentityParams
+		provider, userID, token string
+		wantErr                 bool
+	}{
+		{"provider", IdentityParams{Provider: "apple", UserID: "1", Token: "t"}, "apple", "1", "t", false},
+		{"defaults to facebook", IdentityParams{UserID: "1", Token: "t"}, "facebook", "1", "t", false},
+		{"legacy facebook", IdentityParams{FacebookUserID: "1", FacebookAccessToken: "t"}, "facebook", "1", "t", false},
+		{"legacy without token", IdentityParams{FacebookUserID: "1"}, "facebook", "1", "", true},
+		{"without token", IdentityParams{Provider: "google", UserID: "1"}, "google", "1", "", true},
+		{"empty", IdentityParams{}, "facebook", "", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider, userID, token := test.params.Credentials()
+			if provider != test.provider || userID != test.userID || token != test.token {
+				t.Errorf("Credentials() = %q, %q, %q, want %q, %q, %q", provider, userID, token, test.provider, test.userID, test.token)
+			}
+			if err := test.params.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package identity
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shikang/travote-be/logging"
+	"github.com/shikang/travote-be/utils"
+)
+
+// Signing keys are re-fetched after this long unless the JWKS response says otherwise
+const defaultJWKSCacheDuration = time.Hour
+
+// Unknown key IDs re-fetch the keys at most this often, so bad tokens can't hammer the provider
+const minJWKSRefreshInterval = time.Minute
+
+// Clock skew allowed when checking exp
+const jwtLeeway = time.Minute
+
+// JSONWebKey - Caps for field names, because of json.Marshal requirements
+type JSONWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JSONWebKeySet - Caps for field names, because of json.Marshal requirements
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Iss string          `json:"iss"`
+	Sub string          `json:"sub"`
+	Aud json.RawMessage `json:"aud"`
+	Exp int64           `json:"exp"`
+}
+
+// audiences - aud is either a single string or a list of strings
+func (claims jwtClaims) audiences() []string {
+	var aud string
+	if json.Unmarshal(claims.Aud, &aud) == nil {
+		return []string{aud}
+	}
+
+	auds := []string{}
+	json.Unmarshal(claims.Aud, &auds)
+	return auds
+}
+
+var _ TokenVerifier = (*JWTTokenVerifier)(nil)
+
+// JWTTokenVerifier - TokenVerifier for RS256 signed ID tokens, checked locally against the provider's cached JWKS
+type JWTTokenVerifier struct {
+	JWKSURL  string
+	Issuers  []string
+	Audience string
+	Client   *http.Client
+
+	mutex       sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	expiresAt   time.Time
+	refreshedAt time.Time
+}
+
+// NewGoogleTokenVerifier - TokenVerifier for Google ID tokens issued to the OAuth client clientID
+func NewGoogleTokenVerifier(clientID string) *JWTTokenVerifier {
+	return &JWTTokenVerifier{
+		JWKSURL:  "https://www.googleapis.com/oauth2/v3/certs",
+		Issuers:  []string{"accounts.google.com", "https://accounts.google.com"},
+		Audience: clientID,
+		Client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// NewAppleTokenVerifier - TokenVerifier for Sign in with Apple identity tokens issued to the app or service clientID
+func NewAppleTokenVerifier(clientID string) *JWTTokenVerifier {
+	return &JWTTokenVerifier{
+		JWKSURL:  "https://appleid.apple.com/auth/keys",
+		Issuers:  []string{"https://appleid.apple.com"},
+		Audience: clientID,
+		Client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// fetchKeys - Get the provider's signing keys, and how long they may be cached for
+func (verifier *JWTTokenVerifier) fetchKeys() (map[string]*rsa.PublicKey, time.Duration, error) {
+	resp, err := verifier.Client.Get(verifier.JWKSURL)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, errors.New("JWKS request failed with status " + strconv.Itoa(resp.StatusCode))
+	}
+
+	keySet := JSONWebKeySet{}
+	err = json.NewDecoder(resp.Body).Decode(&keySet)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range keySet.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	return keys, cacheMaxAge(resp.Header.Get("Cache-Control")), nil
+}
+
+// cacheMaxAge - max-age of a Cache-Control header, defaultJWKSCacheDuration if there is none
+func cacheMaxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return defaultJWKSCacheDuration
+}
+
+// getKey - Signing key kid, from the cached keys if they are fresh and include it
+func (verifier *JWTTokenVerifier) getKey(kid string) (*rsa.PublicKey, error) {
+	verifier.mutex.Lock()
+	defer verifier.mutex.Unlock()
+
+	now := time.Now()
+	key, ok := verifier.keys[kid]
+	if ok && now.Before(verifier.expiresAt) {
+		return key, nil
+	}
+
+	// Providers rotate keys, so an unknown kid may be a new key. Keep stale keys if we refreshed recently.
+	if verifier.keys != nil && now.Before(verifier.expiresAt) && now.Sub(verifier.refreshedAt) < minJWKSRefreshInterval {
+		return nil, nil
+	}
+
+	keys, maxAge, err := verifier.fetchKeys()
+	if err != nil {
+		logging.Default().Error("Fetching JWKS failed", logging.Fields{"component": "identity", "jwks_url": verifier.JWKSURL, "error": err})
+		return nil, err
+	}
+
+	verifier.keys = keys
+	verifier.expiresAt = now.Add(maxAge)
+	verifier.refreshedAt = now
+	return keys[kid], nil
+}
+
+// VerifyAccessToken - Check the ID token's signature, issuer, audience, expiry and subject
+func (verifier *JWTTokenVerifier) VerifyAccessToken(userID string, idToken string) TokenStatus {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return TokenInvalid
+	}
+
+	header := jwtHeader{}
+	if DecodeJWTPart(parts[0], &header) != nil || header.Alg != "RS256" {
+		return TokenInvalid
+	}
+
+	key, err := verifier.getKey(header.Kid)
+	if err != nil {
+		return TokenUpstreamFailure
+	} else if key == nil {
+		return TokenInvalid
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return TokenInvalid
+	}
+
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature) != nil {
+		return TokenInvalid
+	}
+
+	claims := jwtClaims{}
+	if DecodeJWTPart(parts[1], &claims) != nil {
+		return TokenInvalid
+	}
+
+	if !utils.ContainsString(verifier.Issuers, claims.Iss) {
+		return TokenInvalid
+	}
+	if !utils.ContainsString(claims.audiences(), verifier.Audience) {
+		return TokenWrongApp
+	}
+	if time.Now().After(time.Unix(claims.Exp, 0).Add(jwtLeeway)) {
+		return TokenExpired
+	}
+	if claims.Sub == "" || claims.Sub != userID {
+		return TokenInvalid
+	}
+
+	return TokenValid
+}
+
+// DecodeJWTPart - Unmarshal the base64url encoded JSON header or claims of a JWT into v
+func DecodeJWTPart(part string, v interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, v)
+}
@@ -0,0 +1,167 @@
+package identity
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "travote-client-id"
+)
+
+// testKeys - RSA keys shared by the tests, generating them is slow
+var testKeys = map[string]*rsa.PrivateKey{}
+
+func testKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+	if key, ok := testKeys[kid]; ok {
+		return key
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Generating RSA key failed: %v", err)
+	}
+	testKeys[kid] = key
+	return key
+}
+
+// newJWKSServer - Server of the public keys of kids, counting the requests made to it
+func newJWKSServer(t *testing.T, requests *int32, kids ...string) *httptest.Server {
+	keySet := JSONWebKeySet{}
+	for _, kid := range kids {
+		key := testKey(t, kid)
+		keySet.Keys = append(keySet.Keys, JSONWebKey{
+			Kid: kid,
+			Kty: "RSA",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		json.NewEncoder(w).Encode(keySet)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestVerifier(server *httptest.Server) *JWTTokenVerifier {
+	return &JWTTokenVerifier{
+		JWKSURL:  server.URL,
+		Issuers:  []string{testIssuer},
+		Audience: testAudience,
+		Client:   server.Client(),
+	}
+}
+
+// signTestJWT - JWT of claims with header, signed with the key kid
+func signTestJWT(t *testing.T, header map[string]string, claims map[string]interface{}, kid string) string {
+	t.Helper()
+	headerJSON, _ := json.Marshal(header)
+	claimsJSON, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	hash := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, testKey(t, kid), crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatalf("Signing JWT failed: %v", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTTokenVerifier(t *testing.T) {
+	var requests int32
+	server := newJWKSServer(t, &requests, "key-1", "key-2")
+	verifier := newTestVerifier(server)
+
+	header := map[string]string{"alg": "RS256", "kid": "key-1"}
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{"iss": testIssuer, "sub": "user-1", "aud": testAudience, "exp": time.Now().Add(time.Hour).Unix()}
+		for name, value := range changes {
+			c[name] = value
+		}
+		return c
+	}
+
+	valid := signTestJWT(t, header, claims(nil), "key-1")
+	tests := []struct {
+		name   string
+		userID string
+		token  string
+		want   TokenStatus
+	}{
+		{"valid", "user-1", valid, TokenValid},
+		{"second key", "user-1", signTestJWT(t, map[string]string{"alg": "RS256", "kid": "key-2"}, claims(nil), "key-2"), TokenValid},
+		{"audience list", "user-1", signTestJWT(t, header, claims(map[string]interface{}{"aud": []string{"other", testAudience}}), "key-1"), TokenValid},
+		{"within leeway", "user-1", signTestJWT(t, header, claims(map[string]interface{}{"exp": time.Now().Add(-30 * time.Second).Unix()}), "key-1"), TokenValid},
+		{"another user", "user-2", valid, TokenInvalid},
+		{"expired", "user-1", signTestJWT(t, header, claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}), "key-1"), TokenExpired},
+		{"another app", "user-1", signTestJWT(t, header, claims(map[string]interface{}{"aud": "other"}), "key-1"), TokenWrongApp},
+		{"another issuer", "user-1", signTestJWT(t, header, claims(map[string]interface{}{"iss": "https://evil.example.com"}), "key-1"), TokenInvalid},
+		{"no subject", "", signTestJWT(t, header, claims(map[string]interface{}{"sub": ""}), "key-1"), TokenInvalid},
+		{"signed with another key", "user-1", signTestJWT(t, header, claims(nil), "key-2"), TokenInvalid},
+		{"unknown key", "user-1", signTestJWT(t, map[string]string{"alg": "RS256", "kid": "key-3"}, claims(nil), "key-3"), TokenInvalid},
+		{"HS256", "user-1", signTestJWT(t, map[string]string{"alg": "HS256", "kid": "key-1"}, claims(nil), "key-1"), TokenInvalid},
+		{"not a JWT", "user-1", "token", TokenInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := verifier.VerifyAccessToken(test.userID, test.token); got != test.want {
+				t.Errorf("VerifyAccessToken() = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	// Keys are cached, and an unknown key doesn't re-fetch them within minJWKSRefreshInterval of the last fetch
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", atomic.LoadInt32(&requests))
+	}
+}
+
+func TestJWTTokenVerifierUpstreamFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	token := signTestJWT(t, map[string]string{"alg": "RS256", "kid": "key-1"}, map[string]interface{}{"iss": testIssuer, "sub": "user-1", "aud": testAudience, "exp": time.Now().Add(time.Hour).Unix()}, "key-1")
+	if got := newTestVerifier(server).VerifyAccessToken("user-1", token); got != TokenUpstreamFailure {
+		t.Errorf("VerifyAccessToken() = %v, want %v", got, TokenUpstreamFailure)
+	}
+}
+
+func TestCacheMaxAge(t *testing.T) {
+	tests := []struct {
+		cacheControl string
+		want         time.Duration
+	}{
+		{"public, max-age=600, must-revalidate", 10 * time.Minute},
+		{"max-age=0", defaultJWKSCacheDuration},
+		{"no-cache", defaultJWKSCacheDuration},
+		{"", defaultJWKSCacheDuration},
+	}
+
+	for _, test := range tests {
+		t.Run(test.cacheControl, func(t *testing.T) {
+			if got := cacheMaxAge(test.cacheControl); got != test.want {
+				t.Errorf("cacheMaxAge(%q) = %v, want %v", test.cacheControl, got, test.want)
+			}
+		})
+	}
+}
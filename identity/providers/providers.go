@@ -0,0 +1,20 @@
+package providers
+
+import (
+	"os"
+
+	"github.com/shikang/travote-be/facebook"
+	"github.com/shikang/travote-be/identity"
+)
+
+// NewIdentityVerifiers - Facebook, plus Google and Apple if their client IDs are configured
+func NewIdentityVerifiers() identity.IdentityVerifiers {
+	verifiers := identity.IdentityVerifiers{identity.ProviderFacebook: facebook.NewFacebookTokenVerifier()}
+	if clientID := os.Getenv("GOOGLE_CLIENT_ID"); clientID != "" {
+		verifiers[identity.ProviderGoogle] = identity.NewGoogleTokenVerifier(clientID)
+	}
+	if clientID := os.Getenv("APPLE_CLIENT_ID"); clientID != "" {
+		verifiers[identity.ProviderApple] = identity.NewAppleTokenVerifier(clientID)
+	}
+	return verifiers
+}
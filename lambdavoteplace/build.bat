@@ -1,3 +0,0 @@
-@echo off
-for %%i in (.) do set folder=%%~nxi
-../build.bat %folder%
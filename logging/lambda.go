@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+)
+
+// LambdaHandler - Signature of the lambdas' Handle*Request functions
+type LambdaHandler func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// outcome - Class of a response status, for filtering logs
+func outcome(statusCode int) string {
+	switch {
+	case statusCode >= 500:
+		return "server_error"
+	case statusCode >= 400:
+		return "client_error"
+	default:
+		return "success"
+	}
+}
+
+// RequestLogger - Logger with the request's API Gateway and Lambda request IDs and the handler name
+func RequestLogger(ctx context.Context, name string, request events.APIGatewayProxyRequest) *Logger {
+	fields := Fields{
+		"request_id": request.RequestContext.RequestID,
+		"handler":    name,
+	}
+	if lambdaContext, ok := lambdacontext.FromContext(ctx); ok {
+		fields["lambda_request_id"] = lambdaContext.AwsRequestID
+	}
+	return Default().With(fields)
+}
+
+// Handler - handler with a RequestLogger in its context, logging the outcome and latency of every request.
+// The error returned with a response is logged rather than returned, as API Gateway would replace the response with a 502.
+func Handler(name string, handler LambdaHandler) LambdaHandler {
+	return func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		start := time.Now()
+		logger := RequestLogger(ctx, name, request)
+
+		response, err := handler(NewContext(ctx, logger), request)
+
+		fields := Fields{
+			"method":     request.HTTPMethod,
+			"path":       request.Path,
+			"status":     response.StatusCode,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"outcome":    outcome(response.StatusCode),
+		}
+		if err != nil {
+			fields["error"] = err
+		}
+
+		// Without a response there is nothing to return but the error
+		if response.StatusCode == 0 && err != nil {
+			logger.Error("Request failed", fields)
+			return response, err
+		}
+
+		switch outcome(response.StatusCode) {
+		case "server_error":
+			logger.Error("Request completed", fields)
+		case "client_error":
+			logger.Warn("Request completed", fields)
+		default:
+			logger.Info("Request completed", fields)
+		}
+		return response, nil
+	}
+}
@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level - Severity of a log line
+type Level string
+
+// Levels of log lines
+const (
+	LevelDebug Level = "debug"
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+)
+
+// Fields - Attributes of a log line, values must marshal to JSON
+type Fields map[string]interface{}
+
+// Redacted - Logged in place of a secret
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys - Fields whose key contains any of these are logged as Redacted
+var sensitiveKeys = []string{"token", "secret", "password", "authorization", "signing_key", "proof"}
+
+// jwtPattern - JWTs inside logged strings, e.g. in error messages
+var jwtPattern = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`)
+
+// Logger - Writes JSON lines with the logger's fields added to every line
+type Logger struct {
+	out    io.Writer
+	mutex  *sync.Mutex
+	fields Fields
+}
+
+// New - Logger writing to out
+func New(out io.Writer) *Logger {
+	return &Logger{out: out, mutex: &sync.Mutex{}, fields: Fields{}}
+}
+
+var defaultLogger = New(os.Stdout)
+
+// Default - Logger writing to stdout, which Lambda sends to CloudWatch
+func Default() *Logger {
+	return defaultLogger
+}
+
+// With - Logger adding fields to every line, on top of the logger's own
+func (logger *Logger) With(fields Fields) *Logger {
+	merged := Fields{}
+	for key, value := range logger.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return &Logger{out: logger.out, mutex: logger.mutex, fields: merged}
+}
+
+// Redact - value, or Redacted if key names a secret. JWTs are removed from strings.
+func Redact(key string, value interface{}) interface{} {
+	lowerKey := strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(lowerKey, sensitive) {
+			return Redacted
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return jwtPattern.ReplaceAllString(v, Redacted)
+	case error:
+		return jwtPattern.ReplaceAllString(v.Error(), Redacted)
+	case map[string]string:
+		redacted := map[string]interface{}{}
+		for k, val := range v {
+			redacted[k] = Redact(k, val)
+		}
+		return redacted
+	case Fields:
+		redacted := map[string]interface{}{}
+		for k, val := range v {
+			redacted[k] = Redact(k, val)
+		}
+		return redacted
+	}
+	return value
+}
+
+// Log - Write a line of level with msg and fields, fields override the logger's own
+func (logger *Logger) Log(level Level, msg string, fields Fields) {
+	line := map[string]interface{}{}
+	for key, value := range logger.fields {
+		line[key] = Redact(key, value)
+	}
+	for key, value := range fields {
+		line[key] = Redact(key, value)
+	}
+	line["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["level"] = level
+	line["msg"] = Redact("msg", msg)
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		data, _ = json.Marshal(map[string]interface{}{"level": LevelError, "msg": "Unloggable fields for: " + msg, "error": err.Error()})
+	}
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	fmt.Fprintln(logger.out, string(data))
+}
+
+// Debug - Write a debug line
+func (logger *Logger) Debug(msg string, fields Fields) {
+	logger.Log(LevelDebug, msg, fields)
+}
+
+// Info - Write an info line
+func (logger *Logger) Info(msg string, fields Fields) {
+	logger.Log(LevelInfo, msg, fields)
+}
+
+// Warn - Write a warn line
+func (logger *Logger) Warn(msg string, fields Fields) {
+	logger.Log(LevelWarn, msg, fields)
+}
+
+// Error - Write an error line
+func (logger *Logger) Error(msg string, fields Fields) {
+	logger.Log(LevelError, msg, fields)
+}
+
+type contextKey struct{}
+
+// NewContext - ctx carrying logger
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext - Logger of ctx, Default if it has none
+func FromContext(ctx context.Context) *Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*Logger); ok {
+		return logger
+	}
+	return Default()
+}
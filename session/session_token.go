@@ -0,0 +1,298 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+
+	"github.com/shikang/travote-be/identity"
+	"github.com/shikang/travote-be/logging"
+)
+
+// SessionKeyInfo - Caps for field names, because of json.Marshal requirements
+type SessionKeyInfo struct {
+	SigningKey string `json:"travote_session_signing_key"`
+}
+
+// Session - Caps for field names, because of json.Marshal requirements
+type Session struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SessionClaims - Caps for field names, because of json.Marshal requirements
+type SessionClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+	// AuthTime - When the user signed in with the identity provider, kept across refreshes
+	AuthTime int64 `json:"auth_time"`
+}
+
+// Session tokens are short-lived, votes stop working this long after a user is banned or the key is rotated
+const sessionTokenDuration = 15 * time.Minute
+
+// Refresh tokens are exchanged for a new session without signing in with the identity provider again,
+// until this long after the user last signed in. Refreshing does not extend it, so revoking the app
+// with the identity provider locks the user out within this long.
+const refreshTokenDuration = 30 * 24 * time.Hour
+
+const sessionIssuer = "travote"
+
+// Token types, so a refresh token can't be used as a session token and vice versa
+const (
+	sessionTokenType = "session"
+	refreshTokenType = "refresh"
+)
+
+// Signing keys shorter than this are rejected, HS256 needs at least 256 bits
+const minSessionSigningKeyLength = 32
+
+// GetSessionKeyInfoFromAWS - Session signing key, stored like TravoteFacebookAppInfo
+func GetSessionKeyInfoFromAWS() (SessionKeyInfo, error) {
+	secretName := "TravoteSessionSigningKey"
+
+	//Create a Secrets Manager client
+	svc := secretsmanager.New(session.New())
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(secretName),
+		VersionStage: aws.String("AWSCURRENT"), // VersionStage defaults to AWSCURRENT if unspecified
+	}
+
+	result, err := svc.GetSecretValue(input)
+	if err != nil {
+		fields := logging.Fields{"component": "session", "secret": secretName, "error": err}
+		if aerr, ok := err.(awserr.Error); ok {
+			fields["aws_error_code"] = aerr.Code()
+		}
+		logging.Default().Error("Getting secret failed", fields)
+		return SessionKeyInfo{}, err
+	}
+
+	var secretString string
+	if result.SecretString != nil {
+		secretString = *result.SecretString
+	} else {
+		err := errors.New("Expected Secret to be in string")
+		logging.Default().Error("Getting secret failed", logging.Fields{"component": "session", "secret": secretName, "error": err})
+
+		return SessionKeyInfo{}, err
+	}
+
+	keyInfo := SessionKeyInfo{}
+	unmarshalErr := json.Unmarshal([]byte(secretString), &keyInfo)
+
+	if unmarshalErr != nil {
+		logging.Default().Error("Unmarshalling secret failed", logging.Fields{"component": "session", "secret": secretName, "error": unmarshalErr})
+		return SessionKeyInfo{}, unmarshalErr
+	}
+
+	if len(keyInfo.SigningKey) < minSessionSigningKeyLength {
+		err := errors.New("Session signing key is too short")
+		logging.Default().Error("Getting secret failed", logging.Fields{"component": "session", "secret": secretName, "error": err})
+		return SessionKeyInfo{}, err
+	}
+
+	return keyInfo, nil
+}
+
+// SessionSigner - Issues and verifies HS256 Travote session and refresh tokens.
+// The signing key is kept across warm invocations.
+type SessionSigner struct {
+	// GetKeyInfo - Source of the signing key, GetSessionKeyInfoFromAWS by default
+	GetKeyInfo func() (SessionKeyInfo, error)
+
+	mutex   sync.Mutex
+	keyInfo *SessionKeyInfo
+}
+
+// NewSessionSigner - SessionSigner with the signing key from AWS secret manager
+func NewSessionSigner() *SessionSigner {
+	return &SessionSigner{GetKeyInfo: GetSessionKeyInfoFromAWS}
+}
+
+// getSigningKey - Signing key, fetched on first use
+func (signer *SessionSigner) getSigningKey() ([]byte, error) {
+	signer.mutex.Lock()
+	defer signer.mutex.Unlock()
+
+	if signer.keyInfo == nil {
+		keyInfo, err := signer.GetKeyInfo()
+		if err != nil {
+			return nil, err
+		}
+		signer.keyInfo = &keyInfo
+	}
+
+	return []byte(signer.keyInfo.SigningKey), nil
+}
+
+// sign - HS256 JWT of claims
+func (signer *SessionSigner) sign(claims SessionClaims) (string, error) {
+	key, err := signer.getSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// newTokenID - Random jti, so tokens issued in the same second differ
+func newTokenID() (string, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// IssueSession - Session and refresh token for the user voterID, who just signed in with the identity provider
+func (signer *SessionSigner) IssueSession(voterID string) (Session, error) {
+	return signer.issueSession(voterID, time.Now(), time.Now())
+}
+
+// issueSession - Session and refresh token for the user voterID who signed in at authTime.
+// No token outlives authTime + refreshTokenDuration.
+func (signer *SessionSigner) issueSession(voterID string, authTime time.Time, now time.Time) (Session, error) {
+	lifetimeEnd := authTime.Add(refreshTokenDuration)
+	sessionEnd := now.Add(sessionTokenDuration)
+	if sessionEnd.After(lifetimeEnd) {
+		sessionEnd = lifetimeEnd
+	}
+
+	tokens := map[string]time.Time{sessionTokenType: sessionEnd, refreshTokenType: lifetimeEnd}
+	signed := map[string]string{}
+	for tokenType, expiresAt := range tokens {
+		id, err := newTokenID()
+		if err != nil {
+			return Session{}, err
+		}
+
+		token, err := signer.sign(SessionClaims{
+			Issuer:    sessionIssuer,
+			Subject:   voterID,
+			Type:      tokenType,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+			ID:        id,
+			AuthTime:  authTime.Unix(),
+		})
+		if err != nil {
+			return Session{}, err
+		}
+		signed[tokenType] = token
+	}
+
+	return Session{
+		AccessToken:  signed[sessionTokenType],
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(sessionEnd.Sub(now) / time.Second),
+		RefreshToken: signed[refreshTokenType],
+	}, nil
+}
+
+// verify - Claims of a token of type tokenType signed with our key
+func (signer *SessionSigner) verify(token string, tokenType string) (SessionClaims, identity.TokenStatus) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return SessionClaims{}, identity.TokenInvalid
+	}
+
+	key, err := signer.getSigningKey()
+	if err != nil {
+		return SessionClaims{}, identity.TokenUpstreamFailure
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return SessionClaims{}, identity.TokenInvalid
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return SessionClaims{}, identity.TokenInvalid
+	}
+
+	// Only our own header is ever signed, but check it rather than trust the signature alone
+	header := map[string]string{}
+	err = identity.DecodeJWTPart(parts[0], &header)
+	if err != nil || header["alg"] != "HS256" {
+		return SessionClaims{}, identity.TokenInvalid
+	}
+
+	claims := SessionClaims{}
+	err = identity.DecodeJWTPart(parts[1], &claims)
+	if err != nil || claims.Issuer != sessionIssuer || claims.Type != tokenType || claims.Subject == "" {
+		return SessionClaims{}, identity.TokenInvalid
+	}
+
+	if !time.Now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return SessionClaims{}, identity.TokenExpired
+	}
+
+	return claims, identity.TokenValid
+}
+
+// VerifySessionToken - User the session token was issued to
+func (signer *SessionSigner) VerifySessionToken(token string) (string, identity.TokenStatus) {
+	claims, status := signer.verify(token, sessionTokenType)
+	return claims.Subject, status
+}
+
+// RefreshSession - New session for the user the refresh token was issued to, ending no later than the refresh token.
+// Refresh tokens without auth_time predate the limit and are rejected, so their users sign in again.
+func (signer *SessionSigner) RefreshSession(refreshToken string) (Session, identity.TokenStatus, error) {
+	claims, status := signer.verify(refreshToken, refreshTokenType)
+	if status != identity.TokenValid {
+		return Session{}, status, nil
+	}
+	if claims.AuthTime == 0 {
+		return Session{}, identity.TokenInvalid, nil
+	}
+
+	authTime := time.Unix(claims.AuthTime, 0)
+	now := time.Now()
+	if !now.Before(authTime.Add(refreshTokenDuration)) {
+		return Session{}, identity.TokenExpired, nil
+	}
+
+	issued, err := signer.issueSession(claims.Subject, authTime, now)
+	return issued, status, err
+}
+
+// BearerToken - Token of an "Authorization: Bearer <token>" header, API Gateway keeps the header's case
+func BearerToken(headers map[string]string) (string, bool) {
+	for name, value := range headers {
+		if strings.EqualFold(name, "Authorization") && len(value) > len("Bearer ") && strings.EqualFold(value[:len("Bearer ")], "Bearer ") {
+			return value[len("Bearer "):], true
+		}
+	}
+	return "", false
+}
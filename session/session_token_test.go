@@ -0,0 +1,226 @@
+package session
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shikang/travote-be/identity"
+)
+
+const testSigningKey = "travote-test-session-signing-key"
+
+func newTestSigner(key string) *SessionSigner {
+	return &SessionSigner{
+		GetKeyInfo: func() (SessionKeyInfo, error) {
+			return SessionKeyInfo{SigningKey: key}, nil
+		},
+	}
+}
+
+// claimsOf - Claims of a token, without checking its signature
+func claimsOf(t *testing.T, token string) SessionClaims {
+	t.Helper()
+	claims := SessionClaims{}
+	err := identity.DecodeJWTPart(strings.Split(token, ".")[1], &claims)
+	if err != nil {
+		t.Fatalf("Decoding claims of %q failed: %v", token, err)
+	}
+	return claims
+}
+
+func TestIssueSession(t *testing.T) {
+	signer := newTestSigner(testSigningKey)
+	issued, err := signer.IssueSession("facebook#123")
+	if err != nil {
+		t.Fatalf("IssueSession() = %v", err)
+	}
+
+	if issued.TokenType != "Bearer" || issued.ExpiresIn != int64(sessionTokenDuration/time.Second) {
+		t.Errorf("IssueSession() = %+v, want a Bearer token expiring in %v", issued, sessionTokenDuration)
+	}
+
+	voterID, status := signer.VerifySessionToken(issued.AccessToken)
+	if status != identity.TokenValid || voterID != "facebook#123" {
+		t.Errorf("VerifySessionToken() = %q, %v, want facebook#123, valid", voterID, status)
+	}
+
+	session, refresh := claimsOf(t, issued.AccessToken), claimsOf(t, issued.RefreshToken)
+	if session.AuthTime == 0 || session.AuthTime != refresh.AuthTime {
+		t.Errorf("IssueSession() auth_time = %d and %d, want the sign in time on both", session.AuthTime, refresh.AuthTime)
+	}
+	if refresh.ExpiresAt != refresh.AuthTime+int64(refreshTokenDuration/time.Second) {
+		t.Errorf("IssueSession() refresh token exp = %d, want auth_time + %v", refresh.ExpiresAt, refreshTokenDuration)
+	}
+	if session.ID == refresh.ID {
+		t.Errorf("IssueSession() jti = %q on both tokens, want them to differ", session.ID)
+	}
+}
+
+func TestVerifySessionToken(t *testing.T) {
+	signer := newTestSigner(testSigningKey)
+	issued, err := signer.IssueSession("google#abc")
+	if err != nil {
+		t.Fatalf("IssueSession() = %v", err)
+	}
+	expired, err := signer.issueSession("google#abc", time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("issueSession() = %v", err)
+	}
+	otherKey, err := newTestSigner(testSigningKey + "-other").IssueSession("google#abc")
+	if err != nil {
+		t.Fatalf("IssueSession() = %v", err)
+	}
+
+	parts := strings.Split(issued.AccessToken, ".")
+	noneHeader := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+
+	tests := []struct {
+		name  string
+		token string
+		want  identity.TokenStatus
+	}{
+		{"valid", issued.AccessToken, identity.TokenValid},
+		{"refresh token", issued.RefreshToken, identity.TokenInvalid},
+		{"expired", expired.AccessToken, identity.TokenExpired},
+		{"signed with another key", otherKey.AccessToken, identity.TokenInvalid},
+		{"tampered header", noneHeader + "." + parts[1] + "." + parts[2], identity.TokenInvalid},
+		{"no signature", parts[0] + "." + parts[1] + ".", identity.TokenInvalid},
+		{"not a JWT", "session", identity.TokenInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, status := signer.VerifySessionToken(test.token); status != test.want {
+				t.Errorf("VerifySessionToken() = %v, want %v", status, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifySessionTokenKeyUnavailable(t *testing.T) {
+	signer := &SessionSigner{GetKeyInfo: func() (SessionKeyInfo, error) {
+		return SessionKeyInfo{}, errors.New("secrets manager unavailable")
+	}}
+
+	if _, status := signer.VerifySessionToken("a.b.c"); status != identity.TokenUpstreamFailure {
+		t.Errorf("VerifySessionToken() = %v, want %v", status, identity.TokenUpstreamFailure)
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	signer := newTestSigner(testSigningKey)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		authTime time.Time
+		want     identity.TokenStatus
+	}{
+		{"just signed in", now, identity.TokenValid},
+		{"signed in a week ago", now.Add(-7 * 24 * time.Hour), identity.TokenValid},
+		{"signed in a minute before the limit", now.Add(-refreshTokenDuration + time.Minute), identity.TokenValid},
+		{"signed in past the limit", now.Add(-refreshTokenDuration - time.Minute), identity.TokenExpired},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issued, err := signer.issueSession("apple#xyz", test.authTime, test.authTime)
+			if err != nil {
+				t.Fatalf("issueSession() = %v", err)
+			}
+
+			refreshed, status, err := signer.RefreshSession(issued.RefreshToken)
+			if err != nil || status != test.want {
+				t.Fatalf("RefreshSession() = %v, %v, want %v", status, err, test.want)
+			}
+			if status != identity.TokenValid {
+				return
+			}
+
+			// Refreshing keeps the sign in time, so no token outlives it by more than refreshTokenDuration
+			lifetimeEnd := test.authTime.Add(refreshTokenDuration).Unix()
+			session, refresh := claimsOf(t, refreshed.AccessToken), claimsOf(t, refreshed.RefreshToken)
+			if session.AuthTime != test.authTime.Unix() || refresh.AuthTime != test.authTime.Unix() {
+				t.Errorf("RefreshSession() auth_time = %d and %d, want %d", session.AuthTime, refresh.AuthTime, test.authTime.Unix())
+			}
+			if refresh.ExpiresAt != lifetimeEnd || session.ExpiresAt > lifetimeEnd {
+				t.Errorf("RefreshSession() exp = %d and %d, want at most %d", session.ExpiresAt, refresh.ExpiresAt, lifetimeEnd)
+			}
+			// exp and iat are truncated to seconds, so expires_in may be a second off
+			if diff := refreshed.ExpiresIn - (session.ExpiresAt - session.IssuedAt); diff < -1 || diff > 1 {
+				t.Errorf("RefreshSession() expires_in = %d, want about %d", refreshed.ExpiresIn, session.ExpiresAt-session.IssuedAt)
+			}
+
+			if voterID, status := signer.VerifySessionToken(refreshed.AccessToken); status != identity.TokenValid || voterID != "apple#xyz" {
+				t.Errorf("VerifySessionToken() = %q, %v, want apple#xyz, valid", voterID, status)
+			}
+		})
+	}
+}
+
+func TestRefreshSessionRejects(t *testing.T) {
+	signer := newTestSigner(testSigningKey)
+	issued, err := signer.IssueSession("facebook#123")
+	if err != nil {
+		t.Fatalf("IssueSession() = %v", err)
+	}
+
+	// Refresh tokens issued before auth_time was added
+	legacy, err := signer.sign(SessionClaims{
+		Issuer:    sessionIssuer,
+		Subject:   "facebook#123",
+		Type:      refreshTokenType,
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(refreshTokenDuration).Unix(),
+		ID:        "legacy",
+	})
+	if err != nil {
+		t.Fatalf("sign() = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  identity.TokenStatus
+	}{
+		{"session token", issued.AccessToken, identity.TokenInvalid},
+		{"no auth_time", legacy, identity.TokenInvalid},
+		{"not a JWT", "refresh", identity.TokenInvalid},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, status, err := signer.RefreshSession(test.token)
+			if err != nil || status != test.want {
+				t.Errorf("RefreshSession() = %v, %v, want %v", status, err, test.want)
+			}
+		})
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+		wantOk  bool
+	}{
+		{"bearer", map[string]string{"Authorization": "Bearer abc"}, "abc", true},
+		{"lower case", map[string]string{"authorization": "bearer abc"}, "abc", true},
+		{"other scheme", map[string]string{"Authorization": "Basic abc"}, "", false},
+		{"empty token", map[string]string{"Authorization": "Bearer "}, "", false},
+		{"no header", map[string]string{}, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := BearerToken(test.headers)
+			if got != test.want || ok != test.wantOk {
+				t.Errorf("BearerToken() = %q, %v, want %q, %v", got, ok, test.want, test.wantOk)
+			}
+		})
+	}
+}
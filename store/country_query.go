@@ -0,0 +1,76 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+// CountryQuery - Country filters, combined with AND. Empty fields are not filtered on.
+type CountryQuery struct {
+	Abbrs      []string // abbr=SG,MY,TH - abbr is one of the values
+	Names      []string // name=Singapore,Malaysia - name is one of the values
+	NamePrefix string   // name_prefix=Sing - name begins with the value, case sensitive
+	NameSearch string   // name_search=sing - name contains the value, case insensitive
+}
+
+// IsEmpty - No filters given
+func (query CountryQuery) IsEmpty() bool {
+	return len(query.Abbrs) == 0 && len(query.Names) == 0 && query.NamePrefix == "" && query.NameSearch == ""
+}
+
+// Condition - Filter expression for the filters DynamoDB can evaluate. False if there are none.
+// NameSearch is not included, as DynamoDB has no case insensitive comparison. See Matches.
+func (query CountryQuery) Condition() (expression.ConditionBuilder, bool) {
+	conds := []expression.ConditionBuilder{}
+	if len(query.Abbrs) > 0 {
+		conds = append(conds, inCondition("abbr", query.Abbrs))
+	}
+	if len(query.Names) > 0 {
+		conds = append(conds, inCondition("name", query.Names))
+	}
+	if query.NamePrefix != "" {
+		conds = append(conds, expression.Name("name").BeginsWith(query.NamePrefix))
+	}
+
+	if len(conds) == 0 {
+		return expression.ConditionBuilder{}, false
+	} else if len(conds) == 1 {
+		return conds[0], true
+	}
+	return expression.And(conds[0], conds[1], conds[2:]...), true
+}
+
+func inCondition(name string, values []string) expression.ConditionBuilder {
+	if len(values) == 1 {
+		return expression.Name(name).Equal(expression.Value(values[0]))
+	}
+
+	operands := []expression.OperandBuilder{}
+	for _, v := range values[1:] {
+		operands = append(operands, expression.Value(v))
+	}
+	return expression.Name(name).In(expression.Value(values[0]), operands...)
+}
+
+// Matches - Whether the country passes the filters DynamoDB can't evaluate
+func (query CountryQuery) Matches(country structs.Country) bool {
+	return query.NameSearch == "" || strings.Contains(strings.ToLower(country.Name), strings.ToLower(query.NameSearch))
+}
+
+// MatchesAll - Whether the country passes every filter, for stores that evaluate filters in Go
+func (query CountryQuery) MatchesAll(country structs.Country) bool {
+	if len(query.Abbrs) > 0 && !utils.ContainsString(query.Abbrs, country.Abbr) {
+		return false
+	}
+	if len(query.Names) > 0 && !utils.ContainsString(query.Names, country.Name) {
+		return false
+	}
+	if !strings.HasPrefix(country.Name, query.NamePrefix) {
+		return false
+	}
+	return query.Matches(country)
+}
@@ -0,0 +1,34 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/shikang/travote-be/structs"
+)
+
+func TestCountryQueryMatchesAll(t *testing.T) {
+	singapore := structs.Country{Abbr: "SG", Name: "Singapore"}
+
+	tests := []struct {
+		name  string
+		query CountryQuery
+		want  bool
+	}{
+		{"no filters", CountryQuery{}, true},
+		{"abbr", CountryQuery{Abbrs: []string{"MY", "SG"}}, true},
+		{"other abbr", CountryQuery{Abbrs: []string{"MY"}}, false},
+		{"name", CountryQuery{Names: []string{"Singapore"}}, true},
+		{"name prefix", CountryQuery{NamePrefix: "Sing"}, true},
+		{"name prefix is case sensitive", CountryQuery{NamePrefix: "sing"}, false},
+		{"name search is case insensitive", CountryQuery{NameSearch: "GAP"}, true},
+		{"every filter must match", CountryQuery{Abbrs: []string{"SG"}, NameSearch: "malay"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.query.MatchesAll(singapore); got != test.want {
+				t.Errorf("MatchesAll() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+var _ CountryStore = (*DynamoCountryStore)(nil)
+
+// DynamoCountryStore - CountryStore backed by the Countries table
+type DynamoCountryStore struct {
+	db *dynamodb.DynamoDB
+}
+
+// NewDynamoCountryStore - CountryStore backed by the Countries table
+func NewDynamoCountryStore(db *dynamodb.DynamoDB) *DynamoCountryStore {
+	return &DynamoCountryStore{db: db}
+}
+
+// GetCountries - Get wrapper
+func (store *DynamoCountryStore) GetCountries(query CountryQuery, limit int64, nextToken string) ([]structs.Country, string, error) {
+	if query.IsEmpty() {
+		return store.GetCountriesWithoutAnyFilters(limit, nextToken)
+	}
+	return store.GetCountriesWithFilter(query, limit, nextToken)
+}
+
+// GetCountriesWithoutAnyFilters - No filter get
+func (store *DynamoCountryStore) GetCountriesWithoutAnyFilters(limit int64, nextToken string) ([]structs.Country, string, error) {
+	startKey, err := utils.DecodeNextToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the scan input parameters
+	params := &dynamodb.ScanInput{
+		TableName:         aws.String("Countries"),
+		Limit:             aws.Int64(limit),
+		ExclusiveStartKey: startKey,
+	}
+
+	// Make the DynamoDB Query API call
+	result, err := store.db.Scan(params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	countries := []structs.Country{}
+	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &countries)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextToken, err = utils.EncodeNextToken(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return countries, nextToken, nil
+}
+
+// GetCountriesWithFilter - Filter get
+func (store *DynamoCountryStore) GetCountriesWithFilter(query CountryQuery, limit int64, nextToken string) ([]structs.Country, string, error) {
+	startKey, err := utils.DecodeNextToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the query input parameters
+	params := &dynamodb.ScanInput{
+		TableName:         aws.String("Countries"),
+		Limit:             aws.Int64(limit),
+		ExclusiveStartKey: startKey,
+	}
+
+	if filt, ok := query.Condition(); ok {
+		expr, err := expression.NewBuilder().WithFilter(filt).Build()
+		if err != nil {
+			return nil, "", err
+		}
+
+		params.ExpressionAttributeNames = expr.Names()
+		params.ExpressionAttributeValues = expr.Values()
+		params.FilterExpression = expr.Filter()
+	}
+
+	matches := func(item map[string]*dynamodb.AttributeValue) bool {
+		country := structs.Country{}
+		err := dynamodbattribute.UnmarshalMap(item, &country)
+		return err == nil && query.Matches(country)
+	}
+
+	items, lastKey, err := utils.ScanFilteredFunc(store.db, params, limit, matches, "abbr")
+	if err != nil {
+		return nil, "", err
+	}
+
+	countries := []structs.Country{}
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &countries)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextToken, err = utils.EncodeNextToken(lastKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return countries, nextToken, nil
+}
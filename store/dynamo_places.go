@@ -0,0 +1,614 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/shikang/travote-be/geo"
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+// Covering cells queried before falling back to scanning the table
+const maxGeohashCells = 64
+
+// BatchGetItem calls of one GetPlacesByKeys, the first and the retries of its unprocessed keys
+const maxBatchGetAttempts = 5
+
+// Wait before the first retry of unprocessed keys, doubled for every retry after it
+var batchGetRetryDelay = 50 * time.Millisecond
+
+var _ PlaceStore = (*DynamoPlaceStore)(nil)
+
+// DynamoPlaceStore - PlaceStore backed by the Places table
+type DynamoPlaceStore struct {
+	db *dynamodb.DynamoDB
+}
+
+// NewDynamoPlaceStore - PlaceStore backed by the Places table
+func NewDynamoPlaceStore(db *dynamodb.DynamoDB) *DynamoPlaceStore {
+	return &DynamoPlaceStore{db: db}
+}
+
+// GetPlaces - No filter get
+func (store *DynamoPlaceStore) GetPlaces(abbr string, limit int64, nextToken string) ([]structs.Place, string, error) {
+	startKey, err := utils.DecodeNextToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the query input parameters
+	params := &dynamodb.QueryInput{
+		TableName: aws.String("Places"),
+		KeyConditions: map[string]*dynamodb.Condition{
+			"abbr": {
+				ComparisonOperator: aws.String("EQ"),
+				AttributeValueList: []*dynamodb.AttributeValue{
+					{
+						S: aws.String(abbr),
+					},
+				},
+			},
+		},
+		Limit:             aws.Int64(limit),
+		ExclusiveStartKey: startKey,
+	}
+
+	// Make the DynamoDB Query API call
+	result, err := store.db.Query(params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	places := []structs.Place{}
+	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &places)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextToken, err = utils.EncodeNextToken(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return places, nextToken, nil
+}
+
+// GetPlacesWithFilter - Filter query by GSI
+func (store *DynamoPlaceStore) GetPlacesWithFilter(abbr string, filter string, val string, limit int64, nextToken string) ([]structs.Place, string, error) {
+	if !utils.ContainsString(PlaceFilters, filter) {
+		return nil, "", ErrUnsupportedFilter
+	}
+
+	startKey, err := utils.DecodeNextToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	keyCond := expression.Key("abbr").Equal(expression.Value(abbr)).And(expression.Key(filter).Equal(expression.Value(val)))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the query input parameters
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 aws.String("Places"),
+		IndexName:                 aws.String("abbr-" + filter + "-index"),
+		Limit:                     aws.Int64(limit),
+		ExclusiveStartKey:         startKey,
+	}
+
+	// Make the DynamoDB Query API call
+	result, err := store.db.Query(params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	places := []structs.Place{}
+	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &places)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextToken, err = utils.EncodeNextToken(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return places, nextToken, nil
+}
+
+// GetPlacesWithMaster - Every place of a country that has a master, reading pages until utils.MaxScanCapacityUnits.
+// abbr-master-index only holds places with a master, so this reads the country's child places and nothing else.
+func (store *DynamoPlaceStore) GetPlacesWithMaster(abbr string) ([]structs.Place, bool, error) {
+	keyCond := expression.Key("abbr").Equal(expression.Value(abbr))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Build the query input parameters
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 aws.String("Places"),
+		IndexName:                 aws.String("abbr-master-index"),
+		ReturnConsumedCapacity:    aws.String(dynamodb.ReturnConsumedCapacityTotal),
+	}
+
+	places := []structs.Place{}
+	consumedUnits := 0.0
+	for {
+		// Make the DynamoDB Query API call
+		result, err := store.db.Query(params)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if result.ConsumedCapacity != nil {
+			consumedUnits += aws.Float64Value(result.ConsumedCapacity.CapacityUnits)
+		}
+
+		page := []structs.Place{}
+		err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &page)
+		if err != nil {
+			return nil, false, err
+		}
+		places = append(places, page...)
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return places, true, nil
+		}
+		if consumedUnits >= utils.MaxScanCapacityUnits {
+			return places, false, nil
+		}
+		params.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// nearbyGeohashCells - Cells to query for the box, finest precision first. Nil if the box needs too many cells.
+func nearbyGeohashCells(box geo.BoundingBox) []string {
+	for precision := geo.GeohashIndexPrecision + 2; precision > geo.GeohashIndexPrecision; precision-- {
+		cells := geo.GeohashCoveringCells(box, precision)
+		if len(cells) <= 16 {
+			return cells
+		}
+	}
+
+	cells := geo.GeohashCoveringCells(box, geo.GeohashIndexPrecision)
+	if len(cells) > maxGeohashCells {
+		return nil
+	}
+	return cells
+}
+
+// nearbyCell - Geohash cell to query, with the least distance from the search point to any place in it
+type nearbyCell struct {
+	Cell          string
+	MinDistanceKm float64
+}
+
+// nearbyOffset - Offset of a next_token from GetPlacesByLongLat's geohash index path, false for scan tokens
+func nearbyOffset(startKey map[string]*dynamodb.AttributeValue) (int64, bool, error) {
+	offset, ok := startKey["offset"]
+	if !ok {
+		return 0, false, nil
+	}
+
+	n, err := strconv.ParseInt(aws.StringValue(offset.N), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false, utils.ErrInvalidNextToken
+	}
+	return n, true, nil
+}
+
+// GetPlacesByLongLat - Places within distance kilometres of (lat, long), nearest first.
+// Queries the geohash index over the cells covering the search circle, nearest cell first, and stops once no
+// unread cell can hold a place nearer than the last of the page. next_token is the offset of the next page.
+// Circles too large for the index fall back to scanning the table; next_token pages of such scans continue the scan.
+// A first page that hits MaxScanCapacityUnits falls back to a scan with what is left of the cap. A later page that
+// hits it returns the places already known to be nearest, which may be fewer than limit.
+func (store *DynamoPlaceStore) GetPlacesByLongLat(abbr string, long float64, lat float64, distance float64, limit int64, nextToken string) ([]structs.NearbyPlace, string, error) {
+	startKey, err := utils.DecodeNextToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	offset, isOffset, err := nearbyOffset(startKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	box := geo.BoundingBoxAround(lat, long, distance)
+	cells := nearbyGeohashCells(box)
+	if cells == nil || (startKey != nil && !isOffset) {
+		if isOffset {
+			return nil, "", utils.ErrInvalidNextToken
+		}
+		return store.ScanPlacesByLongLat(abbr, long, lat, distance, limit, nextToken)
+	}
+
+	nearbyCells := []nearbyCell{}
+	for _, cell := range cells {
+		nearbyCells = append(nearbyCells, nearbyCell{Cell: cell, MinDistanceKm: geo.MinDistanceToBoxKm(lat, long, geo.GeohashCellBox(cell))})
+	}
+	sort.SliceStable(nearbyCells, func(i, j int) bool {
+		return nearbyCells[i].MinDistanceKm < nearbyCells[j].MinDistanceKm
+	})
+
+	places := []structs.NearbyPlace{}
+	consumedUnits := 0.0
+
+	// settledPlaces - Places found so far nearer than anything in unread cells, no later cell can come before them
+	settledPlaces := func(minDistanceKm float64) []structs.NearbyPlace {
+		settled := []structs.NearbyPlace{}
+		for _, place := range places {
+			if place.DistanceKm <= minDistanceKm {
+				settled = append(settled, place)
+			}
+		}
+		return settled
+	}
+
+	// capped - Page once the cap is hit before minDistanceKm. Offset pages can't restart as a scan, whose
+	// next_token would skip or repeat places of the pages already returned.
+	capped := func(minDistanceKm float64) ([]structs.NearbyPlace, string, error) {
+		if offset == 0 {
+			return store.scanPlacesByLongLat(abbr, long, lat, distance, limit, "", utils.MaxScanCapacityUnits-consumedUnits)
+		}
+
+		settled := settledPlaces(minDistanceKm)
+		if int64(len(settled)) <= offset {
+			return nil, "", utils.ErrInvalidNextToken
+		}
+		return nearbyPage(settled, offset, limit, true)
+	}
+
+	complete := true
+	for _, nearby := range nearbyCells {
+		// Stop once the settled places fill the page
+		if int64(len(settledPlaces(nearby.MinDistanceKm))) >= offset+limit {
+			complete = false
+			break
+		}
+
+		if nearby.MinDistanceKm > distance {
+			break
+		}
+
+		if consumedUnits >= utils.MaxScanCapacityUnits {
+			return capped(nearby.MinDistanceKm)
+		}
+
+		cell := nearby.Cell
+		// Partition on the index prefix, narrow down to finer cells with the full geohash
+		keyCond := expression.Key("geohash4").Equal(expression.Value(cell[:geo.GeohashIndexPrecision]))
+		if len(cell) > geo.GeohashIndexPrecision {
+			keyCond = keyCond.And(expression.Key("geohash").BeginsWith(cell))
+		}
+		filt := expression.Name("abbr").Equal(expression.Value(abbr))
+
+		expr, err := expression.NewBuilder().WithKeyCondition(keyCond).WithFilter(filt).Build()
+		if err != nil {
+			return nil, "", err
+		}
+
+		// Build the query input parameters
+		params := &dynamodb.QueryInput{
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			KeyConditionExpression:    expr.KeyCondition(),
+			FilterExpression:          expr.Filter(),
+			TableName:                 aws.String("Places"),
+			IndexName:                 aws.String("geohash4-geohash-index"),
+			ReturnConsumedCapacity:    aws.String(dynamodb.ReturnConsumedCapacityTotal),
+		}
+
+		for {
+			// Make the DynamoDB Query API call
+			result, err := store.db.Query(params)
+			if err != nil {
+				return nil, "", err
+			}
+
+			if result.ConsumedCapacity != nil {
+				consumedUnits += aws.Float64Value(result.ConsumedCapacity.CapacityUnits)
+			}
+
+			cellPlaces := []structs.Place{}
+			err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &cellPlaces)
+			if err != nil {
+				return nil, "", err
+			}
+
+			for _, place := range cellPlaces {
+				distanceKm := geo.HaversineKm(lat, long, place.Lat, place.Long)
+				if distanceKm <= distance {
+					places = append(places, structs.NearbyPlace{Place: place, DistanceKm: distanceKm})
+				}
+			}
+
+			if len(result.LastEvaluatedKey) == 0 {
+				break
+			}
+
+			// A cell cut short would leave out places nearer than the ones returned
+			if consumedUnits >= utils.MaxScanCapacityUnits {
+				return capped(nearby.MinDistanceKm)
+			}
+			params.ExclusiveStartKey = result.LastEvaluatedKey
+		}
+	}
+
+	if offset > int64(len(places)) {
+		return nil, "", utils.ErrInvalidNextToken
+	}
+	return nearbyPage(places, offset, limit, complete)
+}
+
+// nearbyPage - Page of places from offset, nearest first. next_token is the offset of the next page, also
+// after the last place found if the search is not complete.
+func nearbyPage(places []structs.NearbyPlace, offset int64, limit int64, complete bool) ([]structs.NearbyPlace, string, error) {
+	sortNearbyPlaces(places)
+
+	end := offset + limit
+	if end > int64(len(places)) {
+		end = int64(len(places))
+	}
+
+	nextToken := ""
+	if end < int64(len(places)) || !complete {
+		var err error
+		nextToken, err = utils.EncodeNextToken(map[string]*dynamodb.AttributeValue{
+			"offset": {N: aws.String(strconv.FormatInt(end, 10))},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	return places[offset:end], nextToken, nil
+}
+
+// ScanPlacesByLongLat - Places within distance kilometres of (lat, long) by scanning the table, nearest first.
+// Results are sorted within a page, next_token continues the scan rather than the ordering.
+func (store *DynamoPlaceStore) ScanPlacesByLongLat(abbr string, long float64, lat float64, distance float64, limit int64, nextToken string) ([]structs.NearbyPlace, string, error) {
+	return store.scanPlacesByLongLat(abbr, long, lat, distance, limit, nextToken, utils.MaxScanCapacityUnits)
+}
+
+// scanPlacesByLongLat - ScanPlacesByLongLat stopping at maxUnits read capacity units
+func (store *DynamoPlaceStore) scanPlacesByLongLat(abbr string, long float64, lat float64, distance float64, limit int64, nextToken string, maxUnits float64) ([]structs.NearbyPlace, string, error) {
+	startKey, err := utils.DecodeNextToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Narrow the scan to the enclosing box, then cut it down to the circle below
+	box := geo.BoundingBoxAround(lat, long, distance)
+
+	filt := expression.Name("abbr").Equal(expression.Value(abbr))
+
+	latFilt := expression.Name("lat").Between(expression.Value(box.MinLat), expression.Value(box.MaxLat))
+
+	// Box across the antimeridian is two disjoint long ranges, either one can match
+	longFilt := expression.Name("long").Between(expression.Value(box.MinLong), expression.Value(box.MaxLong))
+	if box.MinLong < -180 {
+		longFiltEx := expression.Name("long").Between(expression.Value(box.MinLong+360), expression.Value(180))
+		longFilt = expression.Name("long").Between(expression.Value(-180), expression.Value(box.MaxLong)).Or(longFiltEx)
+	} else if box.MaxLong > 180 {
+		longFiltEx := expression.Name("long").Between(expression.Value(-180), expression.Value(box.MaxLong-360))
+		longFilt = expression.Name("long").Between(expression.Value(box.MinLong), expression.Value(180)).Or(longFiltEx)
+	}
+
+	expr, err := expression.NewBuilder().WithFilter(filt.And(latFilt.And(longFilt))).Build()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the query input parameters
+	params := &dynamodb.ScanInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+		ProjectionExpression:      expr.Projection(),
+		TableName:                 aws.String("Places"),
+		Limit:                     aws.Int64(limit),
+		ExclusiveStartKey:         startKey,
+	}
+
+	withinDistance := func(item map[string]*dynamodb.AttributeValue) bool {
+		place := structs.Place{}
+		err := dynamodbattribute.UnmarshalMap(item, &place)
+		return err == nil && geo.HaversineKm(lat, long, place.Lat, place.Long) <= distance
+	}
+
+	items, lastKey, err := utils.ScanFilteredBudget(store.db, params, limit, maxUnits, withinDistance, "abbr", "id")
+	if err != nil {
+		return nil, "", err
+	}
+
+	matches := []structs.Place{}
+	err = dynamodbattribute.UnmarshalListOfMaps(items, &matches)
+	if err != nil {
+		return nil, "", err
+	}
+
+	places := []structs.NearbyPlace{}
+	for _, place := range matches {
+		places = append(places, structs.NearbyPlace{Place: place, DistanceKm: geo.HaversineKm(lat, long, place.Lat, place.Long)})
+	}
+
+	sortNearbyPlaces(places)
+
+	nextToken, err = utils.EncodeNextToken(lastKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return places, nextToken, nil
+}
+
+// GetTopVotedPlaces - Most voted places of a country, highest first. Category and zone are optional filters.
+// Uses the abbr-votes-index GSI, which only holds places that have been voted for.
+// Filtered queries stop at utils.MaxScanCapacityUnits, so a rare category or zone may return fewer than limit places.
+func (store *DynamoPlaceStore) GetTopVotedPlaces(abbr string, category string, zone string, limit int64) ([]structs.Place, error) {
+	builder := expression.NewBuilder().WithKeyCondition(expression.Key("abbr").Equal(expression.Value(abbr)))
+
+	var filt expression.ConditionBuilder
+	hasFilt := false
+	if category != "" {
+		filt = expression.Name("category").Equal(expression.Value(category))
+		hasFilt = true
+	}
+	if zone != "" {
+		zoneFilt := expression.Name("zone").Equal(expression.Value(zone))
+		if hasFilt {
+			filt = filt.And(zoneFilt)
+		} else {
+			filt = zoneFilt
+		}
+		hasFilt = true
+	}
+	if hasFilt {
+		builder = builder.WithFilter(filt)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the query input parameters
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		FilterExpression:          expr.Filter(),
+		TableName:                 aws.String("Places"),
+		IndexName:                 aws.String("abbr-votes-index"),
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int64(limit),
+		ReturnConsumedCapacity:    aws.String(dynamodb.ReturnConsumedCapacityTotal),
+	}
+
+	// Filters are applied after Limit, so keep reading pages until there are enough places or the capacity cap is hit
+	places := []structs.Place{}
+	consumedUnits := 0.0
+	for {
+		// Make the DynamoDB Query API call
+		result, err := store.db.Query(params)
+		if err != nil {
+			return nil, err
+		}
+
+		page := []structs.Place{}
+		err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		if result.ConsumedCapacity != nil {
+			consumedUnits += aws.Float64Value(result.ConsumedCapacity.CapacityUnits)
+		}
+
+		places = append(places, page...)
+		if int64(len(places)) >= limit || len(result.LastEvaluatedKey) == 0 || consumedUnits >= utils.MaxScanCapacityUnits {
+			break
+		}
+		params.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	if int64(len(places)) > limit {
+		places = places[:limit]
+	}
+
+	return places, nil
+}
+
+// GetPlace - Get a place by its key
+func (store *DynamoPlaceStore) GetPlace(key PlaceKey) (structs.Place, error) {
+	params := &dynamodb.GetItemInput{
+		TableName: aws.String("Places"),
+		Key: map[string]*dynamodb.AttributeValue{
+			"abbr": {S: aws.String(key.Abbr)},
+			"id":   {S: aws.String(key.ID)},
+		},
+	}
+
+	// Make the DynamoDB GetItem API call
+	result, err := store.db.GetItem(params)
+	if err != nil {
+		return structs.Place{}, err
+	}
+	if len(result.Item) == 0 {
+		return structs.Place{}, ErrPlaceNotFound
+	}
+
+	place := structs.Place{}
+	err = dynamodbattribute.UnmarshalMap(result.Item, &place)
+	if err != nil {
+		return structs.Place{}, err
+	}
+
+	return place, nil
+}
+
+// GetPlacesByKeys - Batch get places. BatchGetItem accepts at most 100 keys per call.
+// Unprocessed keys are retried with exponential backoff, an upstream error if some are left after maxBatchGetAttempts.
+func (store *DynamoPlaceStore) GetPlacesByKeys(placeKeys []PlaceKey) ([]structs.Place, error) {
+	places := []structs.Place{}
+	if len(placeKeys) == 0 {
+		return places, nil
+	}
+
+	keys := []map[string]*dynamodb.AttributeValue{}
+	for _, key := range placeKeys {
+		keys = append(keys, map[string]*dynamodb.AttributeValue{
+			"abbr": {S: aws.String(key.Abbr)},
+			"id":   {S: aws.String(key.ID)},
+		})
+	}
+
+	requestItems := map[string]*dynamodb.KeysAndAttributes{
+		"Places": {Keys: keys},
+	}
+
+	// Keep asking for unprocessed keys until DynamoDB has returned all of them, backing off while it throttles
+	delay := batchGetRetryDelay
+	for attempt := 1; len(requestItems) > 0; attempt++ {
+		if attempt > maxBatchGetAttempts {
+			return nil, utils.NewUpstreamError("DynamoDB left places unprocessed", nil)
+		}
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		result, err := store.db.BatchGetItem(&dynamodb.BatchGetItemInput{RequestItems: requestItems})
+		if err != nil {
+			return nil, err
+		}
+
+		batch := []structs.Place{}
+		err = dynamodbattribute.UnmarshalListOfMaps(result.Responses["Places"], &batch)
+		if err != nil {
+			return nil, err
+		}
+
+		places = append(places, batch...)
+		requestItems = result.UnprocessedKeys
+	}
+
+	return places, nil
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/shikang/travote-be/utils"
+)
+
+// newFakeBatchGetDB - DynamoDB client whose BatchGetItem returns one place per call and the other keys as
+// unprocessed, like a throttled table
+func newFakeBatchGetDB(t *testing.T, calls *int32) *dynamodb.DynamoDB {
+	type key map[string]struct{ S string }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		input := struct {
+			RequestItems map[string]struct{ Keys []key }
+		}{}
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			t.Errorf("Decoding BatchGetItem input failed: %v", err)
+		}
+
+		keys := input.RequestItems["Places"].Keys
+		output := map[string]interface{}{
+			"Responses": map[string]interface{}{"Places": []interface{}{map[string]interface{}{
+				"abbr": map[string]string{"S": keys[0]["abbr"].S},
+				"id":   map[string]string{"S": keys[0]["id"].S},
+			}}},
+		}
+		if len(keys) > 1 {
+			output["UnprocessedKeys"] = map[string]interface{}{"Places": map[string]interface{}{"Keys": keys[1:]}}
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("ap-southeast-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	return dynamodb.New(sess)
+}
+
+func TestDynamoPlaceStoreGetPlacesByKeysRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    int
+		calls   int32
+		wantErr bool
+	}{
+		{"no keys", 0, 0, false},
+		{"all processed in the last attempt", maxBatchGetAttempts, maxBatchGetAttempts, false},
+		{"unprocessed after the last attempt", maxBatchGetAttempts + 1, maxBatchGetAttempts, true},
+	}
+
+	delay := batchGetRetryDelay
+	batchGetRetryDelay = 0
+	defer func() { batchGetRetryDelay = delay }()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := int32(0)
+			store := NewDynamoPlaceStore(newFakeBatchGetDB(t, &calls))
+
+			keys := []PlaceKey{}
+			for i := 0; i < test.keys; i++ {
+				keys = append(keys, PlaceKey{Abbr: "SG", ID: "sg-" + strconv.Itoa(i)})
+			}
+
+			places, err := store.GetPlacesByKeys(keys)
+			if test.wantErr {
+				if utils.AsAPIError(err).Code != utils.ErrorCodeUpstreamFailure {
+					t.Errorf("GetPlacesByKeys() = %v, want an upstream failure", err)
+				}
+			} else if err != nil || len(places) != test.keys {
+				t.Errorf("GetPlacesByKeys() = %d places, %v, want %d", len(places), err, test.keys)
+			}
+			if calls := atomic.LoadInt32(&calls); calls != test.calls {
+				t.Errorf("BatchGetItem calls = %d, want %d", calls, test.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/expression"
+
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+var _ VoteStore = (*DynamoVoteStore)(nil)
+
+// DynamoVoteStore - VoteStore backed by the Votes table and the vote counters of the Places table
+type DynamoVoteStore struct {
+	db *dynamodb.DynamoDB
+}
+
+// NewDynamoVoteStore - VoteStore backed by the Votes table and the vote counters of the Places table
+func NewDynamoVoteStore(db *dynamodb.DynamoDB) *DynamoVoteStore {
+	return &DynamoVoteStore{db: db}
+}
+
+// Vote - Store the user's vote and increment the place's vote counter in one transaction
+func (store *DynamoVoteStore) Vote(userID string, place PlaceKey) error {
+	// One vote per user per place, enforced by the Votes table key (user_id, place_id)
+	voteCond := expression.AttributeNotExists(expression.Name("user_id"))
+	voteExpr, err := expression.NewBuilder().WithCondition(voteCond).Build()
+	if err != nil {
+		return err
+	}
+
+	// Only count votes for places that exist and are active, checked in the same transaction as the vote
+	// so a place deleted or deactivated mid-request can't receive it
+	placeCond := expression.AttributeExists(expression.Name("id")).And(expression.Or(
+		expression.AttributeNotExists(expression.Name("inactive")),
+		expression.Name("inactive").Equal(expression.Value(false)),
+	))
+	placeUpdate := expression.Add(expression.Name("votes"), expression.Value(1))
+	placeExpr, err := expression.NewBuilder().WithCondition(placeCond).WithUpdate(placeUpdate).Build()
+	if err != nil {
+		return err
+	}
+
+	params := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Put: &dynamodb.Put{
+					TableName: aws.String("Votes"),
+					Item: map[string]*dynamodb.AttributeValue{
+						"user_id":  {S: aws.String(userID)},
+						"place_id": {S: aws.String(place.ID)},
+						"abbr":     {S: aws.String(place.Abbr)},
+						"voted_at": {N: aws.String(strconv.FormatInt(time.Now().Unix(), 10))},
+					},
+					ConditionExpression:      voteExpr.Condition(),
+					ExpressionAttributeNames: voteExpr.Names(),
+				},
+			},
+			{
+				Update: &dynamodb.Update{
+					TableName: aws.String("Places"),
+					Key: map[string]*dynamodb.AttributeValue{
+						"abbr": {S: aws.String(place.Abbr)},
+						"id":   {S: aws.String(place.ID)},
+					},
+					ConditionExpression:       placeExpr.Condition(),
+					UpdateExpression:          placeExpr.Update(),
+					ExpressionAttributeNames:  placeExpr.Names(),
+					ExpressionAttributeValues: placeExpr.Values(),
+					// An existing place that fails the condition is inactive
+					ReturnValuesOnConditionCheckFailure: aws.String(dynamodb.ReturnValuesOnConditionCheckFailureAllOld),
+				},
+			},
+		},
+	}
+
+	// Make the DynamoDB TransactWriteItems API call
+	_, err = store.db.TransactWriteItems(params)
+	if err != nil {
+		return store.transactionError(err, place, ErrAlreadyVoted)
+	}
+
+	return nil
+}
+
+// Unvote - Remove the user's vote and decrement the place's vote counter in one transaction
+func (store *DynamoVoteStore) Unvote(userID string, place PlaceKey) error {
+	// Only retract votes that exist, otherwise the counter would drift below the number of votes
+	voteCond := expression.AttributeExists(expression.Name("user_id"))
+	voteExpr, err := expression.NewBuilder().WithCondition(voteCond).Build()
+	if err != nil {
+		return err
+	}
+
+	placeCond := expression.AttributeExists(expression.Name("id"))
+	placeUpdate := expression.Add(expression.Name("votes"), expression.Value(-1))
+	placeExpr, err := expression.NewBuilder().WithCondition(placeCond).WithUpdate(placeUpdate).Build()
+	if err != nil {
+		return err
+	}
+
+	params := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []*dynamodb.TransactWriteItem{
+			{
+				Delete: &dynamodb.Delete{
+					TableName: aws.String("Votes"),
+					Key: map[string]*dynamodb.AttributeValue{
+						"user_id":  {S: aws.String(userID)},
+						"place_id": {S: aws.String(place.ID)},
+					},
+					ConditionExpression:      voteExpr.Condition(),
+					ExpressionAttributeNames: voteExpr.Names(),
+				},
+			},
+			{
+				Update: &dynamodb.Update{
+					TableName: aws.String("Places"),
+					Key: map[string]*dynamodb.AttributeValue{
+						"abbr": {S: aws.String(place.Abbr)},
+						"id":   {S: aws.String(place.ID)},
+					},
+					ConditionExpression:       placeExpr.Condition(),
+					UpdateExpression:          placeExpr.Update(),
+					ExpressionAttributeNames:  placeExpr.Names(),
+					ExpressionAttributeValues: placeExpr.Values(),
+				},
+			},
+		},
+	}
+
+	// Make the DynamoDB TransactWriteItems API call
+	_, err = store.db.TransactWriteItems(params)
+	if err != nil {
+		return store.transactionError(err, place, ErrVoteNotFound)
+	}
+
+	return nil
+}
+
+// transactionError - Map a cancelled vote or unvote transaction to the error of its failed condition check.
+// The transaction's items are the vote, failing with voteErr, then the place.
+func (store *DynamoVoteStore) transactionError(err error, place PlaceKey, voteErr error) error {
+	canceledErr, ok := err.(*dynamodb.TransactionCanceledException)
+	if !ok {
+		return err
+	}
+
+	reasons := canceledErr.CancellationReasons
+	if len(reasons) > 1 && aws.StringValue(reasons[1].Code) == "ConditionalCheckFailed" {
+		if len(reasons[1].Item) > 0 {
+			return ErrPlaceInactive
+		}
+		return store.missingPlaceError(place)
+	}
+	if len(reasons) > 0 && aws.StringValue(reasons[0].Code) == "ConditionalCheckFailed" {
+		return voteErr
+	}
+
+	return err
+}
+
+// missingPlaceError - ErrPlaceAbbrMismatch if the place exists in another country, else ErrPlaceNotFound.
+// Place IDs are unique across countries, the id-index GSI finds the place without its abbr.
+func (store *DynamoVoteStore) missingPlaceError(place PlaceKey) error {
+	keyCond := expression.Key("id").Equal(expression.Value(place.ID))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return err
+	}
+
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 aws.String("Places"),
+		IndexName:                 aws.String("id-index"),
+		Limit:                     aws.Int64(1),
+	}
+
+	// Make the DynamoDB Query API call
+	result, err := store.db.Query(params)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Items) > 0 {
+		return ErrPlaceAbbrMismatch
+	}
+	return ErrPlaceNotFound
+}
+
+// GetUserVotes - One page of the user's votes
+func (store *DynamoVoteStore) GetUserVotes(userID string, limit int64, nextToken string) ([]structs.Vote, string, error) {
+	startKey, err := utils.DecodeNextToken(nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	keyCond := expression.Key("user_id").Equal(expression.Value(userID))
+	expr, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Build the query input parameters
+	params := &dynamodb.QueryInput{
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		KeyConditionExpression:    expr.KeyCondition(),
+		TableName:                 aws.String("Votes"),
+		Limit:                     aws.Int64(limit),
+		ExclusiveStartKey:         startKey,
+	}
+
+	// Make the DynamoDB Query API call
+	result, err := store.db.Query(params)
+	if err != nil {
+		return nil, "", err
+	}
+
+	votes := []structs.Vote{}
+	err = dynamodbattribute.UnmarshalListOfMaps(result.Items, &votes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextToken, err = utils.EncodeNextToken(result.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return votes, nextToken, nil
+}
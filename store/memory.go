@@ -0,0 +1,322 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/shikang/travote-be/geo"
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+type memoryVoteKey struct {
+	UserID  string
+	PlaceID string
+}
+
+var _ PlaceStore = (*MemoryStore)(nil)
+var _ CountryStore = (*MemoryStore)(nil)
+var _ VoteStore = (*MemoryStore)(nil)
+
+// MemoryStore - PlaceStore, CountryStore and VoteStore kept in memory, for tests and local development
+type MemoryStore struct {
+	mutex     sync.RWMutex
+	places    map[PlaceKey]structs.Place
+	countries map[string]structs.Country
+	votes     map[memoryVoteKey]structs.Vote
+}
+
+// NewMemoryStore - Empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		places:    map[PlaceKey]structs.Place{},
+		countries: map[string]structs.Country{},
+		votes:     map[memoryVoteKey]structs.Vote{},
+	}
+}
+
+// PutPlace - Add or replace a place
+func (store *MemoryStore) PutPlace(place structs.Place) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.places[PlaceKey{Abbr: place.Abbr, ID: place.ID}] = place
+}
+
+// PutCountry - Add or replace a country
+func (store *MemoryStore) PutCountry(country structs.Country) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.countries[country.Abbr] = country
+}
+
+// memoryPage - Bounds of the page of n items that nextToken points to, and the token of the page after it
+func memoryPage(n int, limit int64, nextToken string) (int, int, string, error) {
+	start := 0
+	startKey, err := utils.DecodeNextToken(nextToken)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if startKey != nil {
+		offset, ok := startKey["offset"]
+		if !ok {
+			return 0, 0, "", utils.ErrInvalidNextToken
+		}
+
+		start, err = strconv.Atoi(aws.StringValue(offset.N))
+		if err != nil || start < 0 || start > n {
+			return 0, 0, "", utils.ErrInvalidNextToken
+		}
+	}
+
+	end := start + int(limit)
+	if end >= n {
+		return start, n, "", nil
+	}
+
+	nextToken, err = utils.EncodeNextToken(map[string]*dynamodb.AttributeValue{
+		"offset": {N: aws.String(strconv.Itoa(end))},
+	})
+	return start, end, nextToken, err
+}
+
+// countryPlaces - Places of a country sorted by id, the order of the Places table
+func (store *MemoryStore) countryPlaces(abbr string) []structs.Place {
+	places := []structs.Place{}
+	for key, place := range store.places {
+		if key.Abbr == abbr {
+			places = append(places, place)
+		}
+	}
+
+	sort.Slice(places, func(i, j int) bool {
+		return places[i].ID < places[j].ID
+	})
+	return places
+}
+
+// GetPlaces - One page of a country's places
+func (store *MemoryStore) GetPlaces(abbr string, limit int64, nextToken string) ([]structs.Place, string, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	places := store.countryPlaces(abbr)
+	start, end, nextToken, err := memoryPage(len(places), limit, nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return places[start:end], nextToken, nil
+}
+
+// GetPlacesWithFilter - One page of a country's places with filter equal to val
+func (store *MemoryStore) GetPlacesWithFilter(abbr string, filter string, val string, limit int64, nextToken string) ([]structs.Place, string, error) {
+	if !utils.ContainsString(PlaceFilters, filter) {
+		return nil, "", ErrUnsupportedFilter
+	}
+
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	places := []structs.Place{}
+	for _, place := range store.countryPlaces(abbr) {
+		if (filter == "category" && place.Category == val) ||
+			(filter == "zone" && place.Zone == val) ||
+			(filter == "master" && place.Master == val) {
+			places = append(places, place)
+		}
+	}
+
+	start, end, nextToken, err := memoryPage(len(places), limit, nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return places[start:end], nextToken, nil
+}
+
+// GetPlacesByLongLat - One page of a country's places within distance kilometres of (lat, long), nearest first
+func (store *MemoryStore) GetPlacesByLongLat(abbr string, long float64, lat float64, distance float64, limit int64, nextToken string) ([]structs.NearbyPlace, string, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	places := []structs.NearbyPlace{}
+	for _, place := range store.countryPlaces(abbr) {
+		distanceKm := geo.HaversineKm(lat, long, place.Lat, place.Long)
+		if distanceKm <= distance {
+			places = append(places, structs.NearbyPlace{Place: place, DistanceKm: distanceKm})
+		}
+	}
+
+	sortNearbyPlaces(places)
+
+	start, end, nextToken, err := memoryPage(len(places), limit, nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return places[start:end], nextToken, nil
+}
+
+// GetTopVotedPlaces - A country's most voted places, highest first
+func (store *MemoryStore) GetTopVotedPlaces(abbr string, category string, zone string, limit int64) ([]structs.Place, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	places := []structs.Place{}
+	for _, place := range store.countryPlaces(abbr) {
+		if place.Votes > 0 && (category == "" || place.Category == category) && (zone == "" || place.Zone == zone) {
+			places = append(places, place)
+		}
+	}
+
+	sort.SliceStable(places, func(i, j int) bool {
+		return places[i].Votes > places[j].Votes
+	})
+
+	if int64(len(places)) > limit {
+		places = places[:limit]
+	}
+	return places, nil
+}
+
+// GetPlacesWithMaster - Every place of a country that has a master
+func (store *MemoryStore) GetPlacesWithMaster(abbr string) ([]structs.Place, bool, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	places := []structs.Place{}
+	for _, place := range store.countryPlaces(abbr) {
+		if place.Master != "" {
+			places = append(places, place)
+		}
+	}
+	return places, true, nil
+}
+
+// GetPlace - Place with the given key
+func (store *MemoryStore) GetPlace(key PlaceKey) (structs.Place, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	place, ok := store.places[key]
+	if !ok {
+		return structs.Place{}, ErrPlaceNotFound
+	}
+	return place, nil
+}
+
+// GetPlacesByKeys - Places with the given keys
+func (store *MemoryStore) GetPlacesByKeys(keys []PlaceKey) ([]structs.Place, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	places := []structs.Place{}
+	for _, key := range keys {
+		if place, ok := store.places[key]; ok {
+			places = append(places, place)
+		}
+	}
+	return places, nil
+}
+
+// GetCountries - One page of the countries matching query, sorted by abbr
+func (store *MemoryStore) GetCountries(query CountryQuery, limit int64, nextToken string) ([]structs.Country, string, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	countries := []structs.Country{}
+	for _, country := range store.countries {
+		if query.MatchesAll(country) {
+			countries = append(countries, country)
+		}
+	}
+
+	sort.Slice(countries, func(i, j int) bool {
+		return countries[i].Abbr < countries[j].Abbr
+	})
+
+	start, end, nextToken, err := memoryPage(len(countries), limit, nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return countries[start:end], nextToken, nil
+}
+
+// missingPlaceError - ErrPlaceAbbrMismatch if the place exists in another country, else ErrPlaceNotFound
+func (store *MemoryStore) missingPlaceError(placeKey PlaceKey) error {
+	for key := range store.places {
+		if key.ID == placeKey.ID {
+			return ErrPlaceAbbrMismatch
+		}
+	}
+	return ErrPlaceNotFound
+}
+
+// Vote - Store the user's vote and increment the place's vote counter, if the place exists and is active
+func (store *MemoryStore) Vote(userID string, placeKey PlaceKey) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	place, ok := store.places[placeKey]
+	if !ok {
+		return store.missingPlaceError(placeKey)
+	} else if place.Inactive {
+		return ErrPlaceInactive
+	}
+
+	voteKey := memoryVoteKey{UserID: userID, PlaceID: placeKey.ID}
+	if _, ok := store.votes[voteKey]; ok {
+		return ErrAlreadyVoted
+	}
+
+	place.Votes++
+	store.places[placeKey] = place
+	store.votes[voteKey] = structs.Vote{UserID: userID, PlaceID: placeKey.ID, Abbr: placeKey.Abbr, VotedAt: time.Now().Unix()}
+	return nil
+}
+
+// Unvote - Remove the user's vote and decrement the place's vote counter
+func (store *MemoryStore) Unvote(userID string, placeKey PlaceKey) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	place, ok := store.places[placeKey]
+	if !ok {
+		return store.missingPlaceError(placeKey)
+	}
+
+	voteKey := memoryVoteKey{UserID: userID, PlaceID: placeKey.ID}
+	if _, ok := store.votes[voteKey]; !ok {
+		return ErrVoteNotFound
+	}
+
+	place.Votes--
+	store.places[placeKey] = place
+	delete(store.votes, voteKey)
+	return nil
+}
+
+// GetUserVotes - One page of the user's votes, sorted by place id
+func (store *MemoryStore) GetUserVotes(userID string, limit int64, nextToken string) ([]structs.Vote, string, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	votes := []structs.Vote{}
+	for key, vote := range store.votes {
+		if key.UserID == userID {
+			votes = append(votes, vote)
+		}
+	}
+
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].PlaceID < votes[j].PlaceID
+	})
+
+	start, end, nextToken, err := memoryPage(len(votes), limit, nextToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return votes[start:end], nextToken, nil
+}
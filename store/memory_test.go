@@ -0,0 +1,186 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+func TestMemoryPage(t *testing.T) {
+	token := func(offset string) string {
+		return encodeTestToken(t, map[string]*dynamodb.AttributeValue{"offset": {N: aws.String(offset)}})
+	}
+
+	tests := []struct {
+		name       string
+		n          int
+		limit      int64
+		nextToken  string
+		start, end int
+		hasNext    bool
+		wantErr    error
+	}{
+		{"first page", 5, 2, "", 0, 2, true, nil},
+		{"last page", 5, 2, token("4"), 4, 5, false, nil},
+		{"exact end", 4, 2, token("2"), 2, 4, false, nil},
+		{"empty", 0, 2, "", 0, 0, false, nil},
+		{"offset past the end", 5, 2, token("6"), 0, 0, false, utils.ErrInvalidNextToken},
+		{"negative offset", 5, 2, token("-1"), 0, 0, false, utils.ErrInvalidNextToken},
+		{"Dynamo key", 5, 2, encodeTestToken(t, map[string]*dynamodb.AttributeValue{"abbr": {S: aws.String("SG")}, "id": {S: aws.String("sg-1")}}), 0, 0, false, utils.ErrInvalidNextToken},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start, end, nextToken, err := memoryPage(test.n, test.limit, test.nextToken)
+			if err != test.wantErr {
+				t.Fatalf("memoryPage() error = %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if start != test.start || end != test.end || (nextToken != "") != test.hasNext {
+				t.Errorf("memoryPage() = %d, %d, %q, want %d, %d, next page %v", start, end, nextToken, test.start, test.end, test.hasNext)
+			}
+		})
+	}
+}
+
+// encodeTestToken - next_token of key
+func encodeTestToken(t *testing.T, key map[string]*dynamodb.AttributeValue) string {
+	t.Helper()
+	token, err := utils.EncodeNextToken(key)
+	if err != nil {
+		t.Fatalf("EncodeNextToken() = %v", err)
+	}
+	return token
+}
+
+func newTestMemoryStore() *MemoryStore {
+	store := NewMemoryStore()
+	store.PutPlace(structs.Place{ID: "sg-1", Abbr: "SG", Category: "Nature", Votes: 1})
+	store.PutPlace(structs.Place{ID: "sg-2", Abbr: "SG", Category: "Hotel", Master: "sg-1"})
+	store.PutPlace(structs.Place{ID: "sg-3", Abbr: "SG", Category: "Nature", Inactive: true})
+	store.PutPlace(structs.Place{ID: "my-1", Abbr: "MY", Category: "Nature"})
+	return store
+}
+
+func TestMemoryStoreVote(t *testing.T) {
+	tests := []struct {
+		name    string
+		unvote  bool
+		key     PlaceKey
+		voted   bool
+		wantErr error
+		votes   int64
+	}{
+		{"vote", false, PlaceKey{Abbr: "SG", ID: "sg-1"}, false, nil, 2},
+		{"vote twice", false, PlaceKey{Abbr: "SG", ID: "sg-1"}, true, ErrAlreadyVoted, 2},
+		{"inactive", false, PlaceKey{Abbr: "SG", ID: "sg-3"}, false, ErrPlaceInactive, 0},
+		{"wrong country", false, PlaceKey{Abbr: "MY", ID: "sg-1"}, false, ErrPlaceAbbrMismatch, 1},
+		{"unknown place", false, PlaceKey{Abbr: "SG", ID: "sg-9"}, false, ErrPlaceNotFound, 1},
+		{"unvote", true, PlaceKey{Abbr: "SG", ID: "sg-1"}, true, nil, 1},
+		{"unvote without a vote", true, PlaceKey{Abbr: "SG", ID: "sg-1"}, false, ErrVoteNotFound, 1},
+		{"unvote unknown place", true, PlaceKey{Abbr: "SG", ID: "sg-9"}, false, ErrPlaceNotFound, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newTestMemoryStore()
+			if test.voted {
+				err := store.Vote("google#1", PlaceKey{Abbr: "SG", ID: "sg-1"})
+				if err != nil {
+					t.Fatalf("Vote() = %v", err)
+				}
+			}
+
+			var err error
+			if test.unvote {
+				err = store.Unvote("google#1", test.key)
+			} else {
+				err = store.Vote("google#1", test.key)
+			}
+			if err != test.wantErr {
+				t.Errorf("Vote() or Unvote() = %v, want %v", err, test.wantErr)
+			}
+
+			// Votes of the place voted for, or of sg-1 if it doesn't exist
+			key := test.key
+			if _, err := store.GetPlace(key); err != nil {
+				key = PlaceKey{Abbr: "SG", ID: "sg-1"}
+			}
+			place, _ := store.GetPlace(key)
+			if place.Votes != test.votes {
+				t.Errorf("Votes of %s = %d, want %d", key.ID, place.Votes, test.votes)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreGetPlacesWithFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		val     string
+		ids     []string
+		wantErr error
+	}{
+		{"category", "category", "Nature", []string{"sg-1", "sg-3"}, nil},
+		{"master", "master", "sg-1", []string{"sg-2"}, nil},
+		{"no match", "zone", "North", []string{}, nil},
+		{"unsupported", "name", "Sentosa", nil, ErrUnsupportedFilter},
+	}
+
+	store := newTestMemoryStore()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			places, _, err := store.GetPlacesWithFilter("SG", test.filter, test.val, 10, "")
+			if err != test.wantErr {
+				t.Fatalf("GetPlacesWithFilter() = %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			ids := []string{}
+			for _, place := range places {
+				ids = append(ids, place.ID)
+			}
+			if !reflect.DeepEqual(ids, test.ids) {
+				t.Errorf("GetPlacesWithFilter() = %v, want %v", ids, test.ids)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreGetPlacesByLongLatTies(t *testing.T) {
+	store := NewMemoryStore()
+	for _, id := range []string{"sg-3", "sg-1", "sg-2"} {
+		store.PutPlace(structs.Place{ID: id, Abbr: "SG", Lat: 1.28, Long: 103.86})
+	}
+
+	ids := []string{}
+	nextToken := ""
+	for {
+		places, next, err := store.GetPlacesByLongLat("SG", 103.86, 1.28, 1, 2, nextToken)
+		if err != nil {
+			t.Fatalf("GetPlacesByLongLat() = %v", err)
+		}
+		for _, place := range places {
+			ids = append(ids, place.ID)
+		}
+		if next == "" {
+			break
+		}
+		nextToken = next
+	}
+
+	want := []string{"sg-1", "sg-2", "sg-3"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("GetPlacesByLongLat() pages = %v, want %v", ids, want)
+	}
+}
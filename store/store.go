@@ -0,0 +1,98 @@
+package store
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/shikang/travote-be/structs"
+	"github.com/shikang/travote-be/utils"
+)
+
+// ErrAlreadyVoted - User has an existing vote for the place
+var ErrAlreadyVoted = utils.NewConflictError(utils.ErrorCodeAlreadyVoted, "Already voted for this place")
+
+// ErrPlaceNotFound - Place does not exist
+var ErrPlaceNotFound = utils.NewNotFoundError(utils.ErrorCodePlaceNotFound, "Place not found")
+
+// ErrPlaceAbbrMismatch - Place exists, but in another country than the given abbr
+var ErrPlaceAbbrMismatch = utils.NewValidationError(utils.ErrorCodePlaceAbbrMismatch, "Place is not in the given country")
+
+// ErrPlaceInactive - Place is flagged inactive and can no longer be voted for
+var ErrPlaceInactive = utils.NewConflictError(utils.ErrorCodePlaceInactive, "Place is inactive")
+
+// ErrVoteNotFound - User has no vote for the place to retract
+var ErrVoteNotFound = utils.NewNotFoundError(utils.ErrorCodeVoteNotFound, "Vote not found")
+
+// PlaceFilters - Place attributes that can be filtered on within a country, each backed by an abbr-<filter>-index GSI
+var PlaceFilters = []string{"category", "zone", "master"}
+
+// ErrUnsupportedFilter - Filter is not one of PlaceFilters
+var ErrUnsupportedFilter = utils.NewValidationError(utils.ErrorCodeUnsupportedFilter, "Unsupported filter, allowed filters: "+strings.Join(PlaceFilters, ", "))
+
+// PlaceKey - Primary key of a place
+type PlaceKey struct {
+	Abbr string
+	ID   string
+}
+
+// PlaceStore - Read access to places
+type PlaceStore interface {
+	// GetPlaces - One page of a country's places
+	GetPlaces(abbr string, limit int64, nextToken string) ([]structs.Place, string, error)
+	// GetPlacesWithFilter - One page of a country's places with filter, one of PlaceFilters, equal to val
+	GetPlacesWithFilter(abbr string, filter string, val string, limit int64, nextToken string) ([]structs.Place, string, error)
+	// GetPlacesByLongLat - A country's places within distance kilometres of (lat, long), nearest first
+	GetPlacesByLongLat(abbr string, long float64, lat float64, distance float64, limit int64, nextToken string) ([]structs.NearbyPlace, string, error)
+	// GetTopVotedPlaces - A country's most voted places, highest first. Category and zone are optional filters.
+	GetTopVotedPlaces(abbr string, category string, zone string, limit int64) ([]structs.Place, error)
+	// GetPlacesWithMaster - Every place of a country that has a master, the links of its place hierarchy.
+	// complete is false if the read stopped at utils.MaxScanCapacityUnits with places left unread.
+	GetPlacesWithMaster(abbr string) (places []structs.Place, complete bool, err error)
+	// GetPlace - Place with the given key, ErrPlaceNotFound if it does not exist
+	GetPlace(key PlaceKey) (structs.Place, error)
+	// GetPlacesByKeys - Places with the given keys. Places that don't exist are left out.
+	GetPlacesByKeys(keys []PlaceKey) ([]structs.Place, error)
+}
+
+// CountryStore - Read access to countries
+type CountryStore interface {
+	// GetCountries - One page of the countries matching query
+	GetCountries(query CountryQuery, limit int64, nextToken string) ([]structs.Country, string, error)
+}
+
+// VoteStore - Votes and the vote counters on places
+type VoteStore interface {
+	// Vote - Store the user's vote and increment the place's vote counter, if the place exists and is active
+	Vote(userID string, place PlaceKey) error
+	// Unvote - Remove the user's vote and decrement the place's vote counter
+	Unvote(userID string, place PlaceKey) error
+	// GetUserVotes - One page of the user's votes
+	GetUserVotes(userID string, limit int64, nextToken string) ([]structs.Vote, string, error)
+}
+
+// NewDynamoDB - DynamoDB client for the lambda's region, AWS_REGION is set by the Lambda runtime
+func NewDynamoDB() *dynamodb.DynamoDB {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = "ap-southeast-1"
+	}
+	return dynamodb.New(session.New(), aws.NewConfig().WithRegion(region))
+}
+
+// sortNearbyPlaces - Nearest first, places at the same distance by abbr and id so pages split them the same way every time
+func sortNearbyPlaces(places []structs.NearbyPlace) {
+	sort.SliceStable(places, func(i, j int) bool {
+		if places[i].DistanceKm != places[j].DistanceKm {
+			return places[i].DistanceKm < places[j].DistanceKm
+		}
+		if places[i].Abbr != places[j].Abbr {
+			return places[i].Abbr < places[j].Abbr
+		}
+		return places[i].ID < places[j].ID
+	})
+}
@@ -1,4 +1,4 @@
-package main
+package structs
 
 // Country - Caps for field names, because of json.Marshal requirements
 type Country struct {
@@ -26,4 +26,38 @@ type Place struct {
 	Email    string  `json:"email"`
 	Zone     string  `json:"zone"`
 	Ext1     string  `json:"ext_1"`
+	Votes    int64   `json:"votes"`
+	Geohash  string  `json:"geohash"`
+	Inactive bool    `json:"inactive"`
+}
+
+// NearbyPlace - Place with its distance from the search point
+type NearbyPlace struct {
+	Place
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// PlaceDetail - Place with its master place, if any, and the places it is the master of.
+// TotalVotes adds the votes of every place below it.
+type PlaceDetail struct {
+	Place
+	TotalVotes  int64   `json:"total_votes"`
+	MasterPlace *Place  `json:"master_place"`
+	Children    []Place `json:"children"`
+}
+
+// PlaceNode - Place with the places it is the master of nested in Children.
+// TotalVotes adds the votes of every place below it.
+type PlaceNode struct {
+	Place
+	TotalVotes int64       `json:"total_votes"`
+	Children   []PlaceNode `json:"children"`
+}
+
+// Vote - Caps for field names, because of json.Marshal requirements
+type Vote struct {
+	UserID  string `json:"user_id"`
+	PlaceID string `json:"place_id"`
+	Abbr    string `json:"abbr"`
+	VotedAt int64  `json:"voted_at"`
 }
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorKind - Class of an APIError, deciding its HTTP status
+type ErrorKind int
+
+const (
+	// KindValidation - The request is malformed or has an invalid parameter
+	KindValidation ErrorKind = iota
+	// KindAuth - The caller could not be authenticated
+	KindAuth
+	// KindNotFound - A resource the request refers to does not exist
+	KindNotFound
+	// KindConflict - The request conflicts with the current state, e.g. a repeated vote
+	KindConflict
+	// KindMethodNotAllowed - The lambda does not handle the HTTP method
+	KindMethodNotAllowed
+	// KindUpstream - A service the lambda depends on failed
+	KindUpstream
+	// KindInternal - Anything else
+	KindInternal
+)
+
+// StatusCode - HTTP status of errors of the kind
+func (kind ErrorKind) StatusCode() int {
+	switch kind {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindAuth:
+		return http.StatusUnauthorized
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case KindUpstream:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// APIError - Error with the code and message shown to clients. Cause is only logged.
+type APIError struct {
+	Kind    ErrorKind
+	Code    ErrorCode
+	Message string
+	Cause   error
+
+	// Allow - Methods the lambda handles, for KindMethodNotAllowed
+	Allow []string
+
+	// Fields - Invalid parameters or body fields, for KindValidation
+	Fields []FieldError
+}
+
+func (err *APIError) Error() string {
+	if err.Cause != nil {
+		return err.Message + ": " + err.Cause.Error()
+	}
+	return err.Message
+}
+
+func (err *APIError) Unwrap() error {
+	return err.Cause
+}
+
+// NewValidationError - 400 error
+func NewValidationError(code ErrorCode, message string) *APIError {
+	return &APIError{Kind: KindValidation, Code: code, Message: message}
+}
+
+// NewAuthError - 401 error
+func NewAuthError(code ErrorCode, message string) *APIError {
+	return &APIError{Kind: KindAuth, Code: code, Message: message}
+}
+
+// NewNotFoundError - 404 error
+func NewNotFoundError(code ErrorCode, message string) *APIError {
+	return &APIError{Kind: KindNotFound, Code: code, Message: message}
+}
+
+// NewConflictError - 409 error
+func NewConflictError(code ErrorCode, message string) *APIError {
+	return &APIError{Kind: KindConflict, Code: code, Message: message}
+}
+
+// NewMethodNotAllowedError - 405 error for method, listing the allowed methods
+func NewMethodNotAllowedError(method string, allow ...string) *APIError {
+	return &APIError{Kind: KindMethodNotAllowed, Code: ErrorCodeMethodNotAllowed, Message: "Method " + method + " not allowed", Allow: allow}
+}
+
+// NewUpstreamError - 502 error, caused by cause
+func NewUpstreamError(message string, cause error) *APIError {
+	return &APIError{Kind: KindUpstream, Code: ErrorCodeUpstreamFailure, Message: message, Cause: cause}
+}
+
+// NewInternalError - 500 error, caused by cause which is not shown to clients
+func NewInternalError(cause error) *APIError {
+	return &APIError{Kind: KindInternal, Code: ErrorCodeInternal, Message: "Internal server error", Cause: cause}
+}
+
+// AsAPIError - err if it is an APIError, else an internal error caused by err
+func AsAPIError(err error) *APIError {
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return NewInternalError(err)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// MaxPageLimit - Largest limit of a page, the limit query string parameter is checked against it
+const MaxPageLimit = 200
+
+// ErrInvalidNextToken - Cursor was not produced by EncodeNextToken
+var ErrInvalidNextToken = NewValidationError(ErrorCodeInvalidNextToken, "Invalid next_token")
+
+// EncodeNextToken - Opaque cursor from DynamoDB LastEvaluatedKey, empty if there are no more pages
+func EncodeNextToken(lastEvaluatedKey map[string]*dynamodb.AttributeValue) (string, error) {
+	if len(lastEvaluatedKey) == 0 {
+		return "", nil
+	}
+
+	key := map[string]interface{}{}
+	err := dynamodbattribute.UnmarshalMap(lastEvaluatedKey, &key)
+	if err != nil {
+		return "", err
+	}
+
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(keyJSON), nil
+}
+
+// DecodeNextToken - DynamoDB ExclusiveStartKey from cursor, nil if token is empty
+func DecodeNextToken(token string) (map[string]*dynamodb.AttributeValue, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	keyJSON, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidNextToken
+	}
+
+	key := map[string]interface{}{}
+	err = json.Unmarshal(keyJSON, &key)
+	if err != nil || len(key) == 0 {
+		return nil, ErrInvalidNextToken
+	}
+
+	return dynamodbattribute.MarshalMap(key)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+func TestNextTokenRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		key  map[string]*dynamodb.AttributeValue
+	}{
+		{"table key", map[string]*dynamodb.AttributeValue{
+			"abbr": {S: aws.String("SG")},
+			"id":   {S: aws.String("sg-1")},
+		}},
+		{"index key with a number", map[string]*dynamodb.AttributeValue{
+			"abbr":  {S: aws.String("SG")},
+			"id":    {S: aws.String("sg-1")},
+			"votes": {N: aws.String("42")},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := EncodeNextToken(test.key)
+			if err != nil {
+				t.Fatalf("EncodeNextToken() = %v", err)
+			}
+
+			key, err := DecodeNextToken(token)
+			if err != nil {
+				t.Fatalf("DecodeNextToken(%q) = %v", token, err)
+			}
+			if !reflect.DeepEqual(key, test.key) {
+				t.Errorf("DecodeNextToken(EncodeNextToken(key)) = %v, want %v", key, test.key)
+			}
+		})
+	}
+}
+
+func TestEncodeNextTokenEmpty(t *testing.T) {
+	for _, key := range []map[string]*dynamodb.AttributeValue{nil, {}} {
+		token, err := EncodeNextToken(key)
+		if err != nil || token != "" {
+			t.Errorf("EncodeNextToken(%v) = %q, %v, want \"\", nil", key, token, err)
+		}
+	}
+}
+
+func TestDecodeNextToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantNil bool
+		wantErr error
+	}{
+		{"empty", "", true, nil},
+		{"not base64", "!!!", true, ErrInvalidNextToken},
+		{"not JSON", base64.RawURLEncoding.EncodeToString([]byte("abbr=SG")), true, ErrInvalidNextToken},
+		{"empty object", base64.RawURLEncoding.EncodeToString([]byte("{}")), true, ErrInvalidNextToken},
+		{"array", base64.RawURLEncoding.EncodeToString([]byte(`["SG"]`)), true, ErrInvalidNextToken},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"id":"sg-1"}`)), true, ErrInvalidNextToken},
+		{"valid", base64.RawURLEncoding.EncodeToString([]byte(`{"id":"sg-1"}`)), false, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := DecodeNextToken(test.token)
+			if err != test.wantErr {
+				t.Errorf("DecodeNextToken(%q) error = %v, want %v", test.token, err, test.wantErr)
+			}
+			if (key == nil) != test.wantNil {
+				t.Errorf("DecodeNextToken(%q) = %v, want nil %v", test.token, key, test.wantNil)
+			}
+		})
+	}
+}
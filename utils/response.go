@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ErrorCode - Machine readable code of an error response, stable across message changes
+type ErrorCode string
+
+// Error codes of error responses
+const (
+	ErrorCodeBadRequest          ErrorCode = "bad_request"
+	ErrorCodeInvalidParameter    ErrorCode = "invalid_parameter"
+	ErrorCodeInvalidNextToken    ErrorCode = "invalid_next_token"
+	ErrorCodeUnsupportedFilter   ErrorCode = "unsupported_filter"
+	ErrorCodeUnsupportedProvider ErrorCode = "unsupported_provider"
+	ErrorCodeInvalidToken        ErrorCode = "invalid_token"
+	ErrorCodeTokenExpired        ErrorCode = "token_expired"
+	ErrorCodeTokenWrongApp       ErrorCode = "token_wrong_app"
+	ErrorCodeTokenMissingScopes  ErrorCode = "token_missing_scopes"
+	ErrorCodePlaceNotFound       ErrorCode = "place_not_found"
+	ErrorCodePlaceAbbrMismatch   ErrorCode = "place_abbr_mismatch"
+	ErrorCodePlaceInactive       ErrorCode = "place_inactive"
+	ErrorCodeVoteNotFound        ErrorCode = "vote_not_found"
+	ErrorCodeAlreadyVoted        ErrorCode = "already_voted"
+	ErrorCodeMethodNotAllowed    ErrorCode = "method_not_allowed"
+	ErrorCodeUpstreamFailure     ErrorCode = "upstream_failure"
+	ErrorCodeInternal            ErrorCode = "internal_error"
+)
+
+// ResponseError - Caps for field names, because of json.Marshal requirements
+type ResponseError struct {
+	Code    ErrorCode    `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// Envelope - Body of every response. Data is set on success, Error on failure.
+type Envelope struct {
+	Data      interface{}    `json:"data"`
+	Error     *ResponseError `json:"error,omitempty"`
+	NextToken string         `json:"next_token,omitempty"`
+	RequestID string         `json:"request_id,omitempty"`
+}
+
+// generateEnvelopeResponse - Response with envelope as the JSON body
+func generateEnvelopeResponse(envelope Envelope, statusCode int) (events.APIGatewayProxyResponse, error) {
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	apiResponse := events.APIGatewayProxyResponse{
+		Headers: map[string]string{
+			"Content-Type":                 "application/json",
+			"Access-Control-Allow-Origin":  "*",
+			"Access-Control-Allow-Headers": "Content-Type,Authorization,X-Identity-Provider,X-Identity-User-Id,X-Identity-Token",
+			"Access-Control-Allow-Methods": "OPTIONS,GET,POST,PUT,DELETE",
+		},
+		Body:       string(body),
+		StatusCode: statusCode}
+	return apiResponse, err
+}
+
+// GenerateResponse - Create success response with data and the token of the next page, if any
+func GenerateResponse(request events.APIGatewayProxyRequest, data interface{}, nextToken string) (events.APIGatewayProxyResponse, error) {
+	apiResponse, err := generateEnvelopeResponse(Envelope{
+		Data:      data,
+		NextToken: nextToken,
+		RequestID: request.RequestContext.RequestID,
+	}, http.StatusOK)
+	if err != nil {
+		return GenerateErrorResponse(request, NewInternalError(err))
+	}
+	return apiResponse, err
+}
+
+// GenerateErrorResponse - Create error response with the status of err's kind, errors other than APIError are internal.
+// err is returned with the response for logging.Handler to log, only the APIError's code and message are shown to clients.
+func GenerateErrorResponse(request events.APIGatewayProxyRequest, err error) (events.APIGatewayProxyResponse, error) {
+	apiErr := AsAPIError(err)
+
+	// An envelope of strings always marshals
+	apiResponse, _ := generateEnvelopeResponse(Envelope{
+		Error:     &ResponseError{Code: apiErr.Code, Message: apiErr.Message, Fields: apiErr.Fields},
+		RequestID: request.RequestContext.RequestID,
+	}, apiErr.Kind.StatusCode())
+
+	if len(apiErr.Allow) > 0 {
+		apiResponse.Headers["Allow"] = strings.Join(apiErr.Allow, ", ")
+	}
+	return apiResponse, err
+}
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// MaxScanCapacityUnits - Read capacity units a single filtered scan may consume, override with MAX_SCAN_CAPACITY_UNITS
+var MaxScanCapacityUnits = capacityUnitsFromEnv("MAX_SCAN_CAPACITY_UNITS", 50)
+
+func capacityUnitsFromEnv(name string, fallback float64) float64 {
+	units, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil || units <= 0 {
+		return fallback
+	}
+	return units
+}
+
+// ScanFiltered - Scan until limit items pass the filter, the table is exhausted or the read capacity cap is hit.
+// Limit on a filtered scan caps items examined, not items returned, so one page may hold no matches at all.
+// Returns the matched items and the key to resume from, nil if the table is exhausted.
+// keyNames are the table's primary key attributes, used to resume after the last returned item.
+func ScanFiltered(svc *dynamodb.DynamoDB, params *dynamodb.ScanInput, limit int64, keyNames ...string) ([]map[string]*dynamodb.AttributeValue, map[string]*dynamodb.AttributeValue, error) {
+	return ScanFilteredFunc(svc, params, limit, nil, keyNames...)
+}
+
+// ScanFilteredFunc - ScanFiltered with an extra filter for conditions FilterExpression can't express.
+// Items are only counted towards limit if accept returns true. A nil accept keeps every item.
+func ScanFilteredFunc(svc *dynamodb.DynamoDB, params *dynamodb.ScanInput, limit int64, accept func(map[string]*dynamodb.AttributeValue) bool, keyNames ...string) ([]map[string]*dynamodb.AttributeValue, map[string]*dynamodb.AttributeValue, error) {
+	return ScanFilteredBudget(svc, params, limit, MaxScanCapacityUnits, accept, keyNames...)
+}
+
+// ScanFilteredBudget - ScanFilteredFunc capped at maxUnits instead of MaxScanCapacityUnits, for requests that
+// already spent part of the cap. The first page is always read, however small the budget.
+func ScanFilteredBudget(svc *dynamodb.DynamoDB, params *dynamodb.ScanInput, limit int64, maxUnits float64, accept func(map[string]*dynamodb.AttributeValue) bool, keyNames ...string) ([]map[string]*dynamodb.AttributeValue, map[string]*dynamodb.AttributeValue, error) {
+	params.ReturnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityTotal)
+
+	items := []map[string]*dynamodb.AttributeValue{}
+	consumedUnits := 0.0
+	for {
+		// Make the DynamoDB Scan API call
+		result, err := svc.Scan(params)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if result.ConsumedCapacity != nil {
+			consumedUnits += aws.Float64Value(result.ConsumedCapacity.CapacityUnits)
+		}
+
+		for _, item := range result.Items {
+			if accept == nil || accept(item) {
+				items = append(items, item)
+			}
+		}
+
+		if int64(len(items)) > limit {
+			// Resume right after the last item returned, not after the page
+			items = items[:limit]
+			lastItem := items[limit-1]
+			lastKey := map[string]*dynamodb.AttributeValue{}
+			for _, name := range keyNames {
+				lastKey[name] = lastItem[name]
+			}
+			return items, lastKey, nil
+		}
+
+		if int64(len(items)) == limit || len(result.LastEvaluatedKey) == 0 || consumedUnits >= maxUnits {
+			return items, result.LastEvaluatedKey, nil
+		}
+
+		params.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// fakeScanItem - Item of the table served by newFakeScanDB
+type fakeScanItem struct {
+	ID   string
+	Keep bool
+}
+
+// newFakeScanDB - DynamoDB client whose Scan reads items, pageSize at a time in order of ID,
+// each page consuming unitsPerPage read capacity units
+func newFakeScanDB(t *testing.T, items []fakeScanItem, pageSize int, unitsPerPage float64) *dynamodb.DynamoDB {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		input := struct {
+			ExclusiveStartKey map[string]struct{ S string }
+		}{}
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			t.Errorf("Decoding Scan input failed: %v", err)
+		}
+
+		start := 0
+		if startKey, ok := input.ExclusiveStartKey["id"]; ok {
+			for i, item := range items {
+				if item.ID == startKey.S {
+					start = i + 1
+				}
+			}
+		}
+		end := start + pageSize
+		if end > len(items) {
+			end = len(items)
+		}
+
+		output := map[string]interface{}{"ConsumedCapacity": map[string]interface{}{"CapacityUnits": unitsPerPage}}
+		page := []interface{}{}
+		for _, item := range items[start:end] {
+			page = append(page, map[string]interface{}{"id": map[string]string{"S": item.ID}, "keep": map[string]bool{"BOOL": item.Keep}})
+		}
+		output["Items"] = page
+		if end < len(items) {
+			output["LastEvaluatedKey"] = map[string]interface{}{"id": map[string]string{"S": items[end-1].ID}}
+		}
+
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		json.NewEncoder(w).Encode(output)
+	}))
+	t.Cleanup(server.Close)
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("ap-southeast-1"),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+	}))
+	return dynamodb.New(sess)
+}
+
+func TestScanFilteredFunc(t *testing.T) {
+	items := []fakeScanItem{{"a", true}, {"b", false}, {"c", true}, {"d", true}, {"e", true}, {"f", false}}
+	accept := func(item map[string]*dynamodb.AttributeValue) bool {
+		return aws.BoolValue(item["keep"].BOOL)
+	}
+
+	tests := []struct {
+		name      string
+		limit     int64
+		maxUnits  float64
+		wantIDs   []string
+		wantStart string
+	}{
+		// The second page has c and d, resuming after d would skip it
+		{"limit inside a page resumes after the last item", 2, 50, []string{"a", "c"}, "c"},
+		{"limit at the end of a page resumes after the page", 3, 50, []string{"a", "c", "d"}, "d"},
+		{"exhausted table has no resume key", 10, 50, []string{"a", "c", "d", "e"}, ""},
+		{"capacity cap resumes after the last page read", 10, 2, []string{"a", "c", "d"}, "d"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			maxUnits := MaxScanCapacityUnits
+			MaxScanCapacityUnits = test.maxUnits
+			defer func() { MaxScanCapacityUnits = maxUnits }()
+
+			db := newFakeScanDB(t, items, 2, 1)
+			found, startKey, err := ScanFilteredFunc(db, &dynamodb.ScanInput{TableName: aws.String("Places")}, test.limit, accept, "id")
+			if err != nil {
+				t.Fatalf("ScanFilteredFunc() = %v", err)
+			}
+
+			ids := []string{}
+			for _, item := range found {
+				ids = append(ids, aws.StringValue(item["id"].S))
+			}
+			if !reflect.DeepEqual(ids, test.wantIDs) {
+				t.Errorf("ScanFilteredFunc() items = %v, want %v", ids, test.wantIDs)
+			}
+
+			start := ""
+			if startKey != nil {
+				start = aws.StringValue(startKey["id"].S)
+			}
+			if start != test.wantStart {
+				t.Errorf("ScanFilteredFunc() resume key = %q, want %q", start, test.wantStart)
+			}
+		})
+	}
+}
+
+func TestScanFilteredResumes(t *testing.T) {
+	items := []fakeScanItem{{"a", true}, {"b", true}, {"c", true}, {"d", true}, {"e", true}}
+	db := newFakeScanDB(t, items, 3, 1)
+
+	ids := []string{}
+	params := &dynamodb.ScanInput{TableName: aws.String("Places")}
+	for pages := 0; ; pages++ {
+		if pages > len(items) {
+			t.Fatalf("ScanFiltered() did not finish after %d pages", pages)
+		}
+
+		found, startKey, err := ScanFiltered(db, params, 2, "id")
+		if err != nil {
+			t.Fatalf("ScanFiltered() = %v", err)
+		}
+		for _, item := range found {
+			ids = append(ids, aws.StringValue(item["id"].S))
+		}
+
+		if startKey == nil {
+			break
+		}
+		params = &dynamodb.ScanInput{TableName: aws.String("Places"), ExclusiveStartKey: startKey}
+	}
+
+	want := []string{"a", "b", "c", "d", "e"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("ScanFiltered() pages = %v, want %v with none skipped or repeated", ids, want)
+	}
+}
+
+func TestScanFilteredBudget(t *testing.T) {
+	items := []fakeScanItem{{"a", true}, {"b", true}, {"c", true}, {"d", true}, {"e", true}}
+
+	tests := []struct {
+		name     string
+		maxUnits float64
+		wantIDs  []string
+	}{
+		{"spent budget reads one page", 0, []string{"a", "b"}},
+		{"remaining budget", 2, []string{"a", "b", "c", "d"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := newFakeScanDB(t, items, 2, 1)
+			found, _, err := ScanFilteredBudget(db, &dynamodb.ScanInput{TableName: aws.String("Places")}, 10, test.maxUnits, nil, "id")
+			if err != nil {
+				t.Fatalf("ScanFilteredBudget() = %v", err)
+			}
+
+			ids := []string{}
+			for _, item := range found {
+				ids = append(ids, aws.StringValue(item["id"].S))
+			}
+			if !reflect.DeepEqual(ids, test.wantIDs) {
+				t.Errorf("ScanFilteredBudget() items = %v, want %v", ids, test.wantIDs)
+			}
+		})
+	}
+}
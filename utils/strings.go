@@ -0,0 +1,11 @@
+package utils
+
+// ContainsString - Whether s is in list
+func ContainsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
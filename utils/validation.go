@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldError - Caps for field names, because of json.Marshal requirements
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Rule - Check of a parameter or body field
+type Rule struct {
+	// required - Missing fields fail the rule, other rules skip them
+	required bool
+	// check - Why a present value is invalid given every value, or "" if it is valid
+	check func(value string, values map[string]string) string
+}
+
+// Rules - Rules of each parameter or body field, checked in order until one fails
+type Rules map[string][]Rule
+
+// Required - Field must be present and not blank
+func Required() Rule {
+	return Rule{required: true, check: func(value string, _ map[string]string) string {
+		if strings.TrimSpace(value) == "" {
+			return "is required"
+		}
+		return ""
+	}}
+}
+
+// IntRange - Field must be an integer from min to max
+func IntRange(min int64, max int64) Rule {
+	return Rule{check: func(value string, _ map[string]string) string {
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || i < min || i > max {
+			return "must be an integer from " + strconv.FormatInt(min, 10) + " to " + strconv.FormatInt(max, 10)
+		}
+		return ""
+	}}
+}
+
+// FloatRange - Field must be a number from min to max
+func FloatRange(min float64, max float64) Rule {
+	return Rule{check: func(value string, _ map[string]string) string {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || f < min || f > max {
+			return "must be a number from " + strconv.FormatFloat(min, 'f', -1, 64) + " to " + strconv.FormatFloat(max, 'f', -1, 64)
+		}
+		return ""
+	}}
+}
+
+// OneOf - Field must be one of values
+func OneOf(values ...string) Rule {
+	return Rule{check: func(value string, _ map[string]string) string {
+		if !ContainsString(values, value) {
+			return "must be one of: " + strings.Join(values, ", ")
+		}
+		return ""
+	}}
+}
+
+// MaxLength - Field must be at most max characters
+func MaxLength(max int) Rule {
+	return Rule{check: func(value string, _ map[string]string) string {
+		if len([]rune(value)) > max {
+			return "must be at most " + strconv.Itoa(max) + " characters"
+		}
+		return ""
+	}}
+}
+
+// ListLength - Field must be a comma separated list of min to max values, see SplitList
+func ListLength(min int, max int) Rule {
+	return Rule{check: func(value string, _ map[string]string) string {
+		if n := len(SplitList(value)); n < min || n > max {
+			return "must have from " + strconv.Itoa(min) + " to " + strconv.Itoa(max) + " comma separated values"
+		}
+		return ""
+	}}
+}
+
+// SplitList - Values of a comma separated list, trimmed, blank values left out
+func SplitList(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Rejected - Field must not be present, message says why
+func Rejected(message string) Rule {
+	return Rule{check: func(_ string, _ map[string]string) string {
+		return message
+	}}
+}
+
+// RequiredWith - Field can only be given together with every one of fields
+func RequiredWith(fields ...string) Rule {
+	return Rule{check: func(value string, values map[string]string) string {
+		for _, field := range fields {
+			if _, ok := values[field]; !ok {
+				return "must be given together with " + strings.Join(fields, " and ")
+			}
+		}
+		return ""
+	}}
+}
+
+// ExcludedWith - Field can't be given together with any of fields
+func ExcludedWith(fields ...string) Rule {
+	return Rule{check: func(value string, values map[string]string) string {
+		for _, field := range fields {
+			if _, ok := values[field]; ok {
+				return "can't be combined with " + field
+			}
+		}
+		return ""
+	}}
+}
+
+// isRequired - Whether rules include Required
+func isRequired(rules []Rule) bool {
+	for _, rule := range rules {
+		if rule.required {
+			return true
+		}
+	}
+	return false
+}
+
+// NewFieldValidationError - 400 error listing the invalid fields
+func NewFieldValidationError(fieldErrors []FieldError) *APIError {
+	fields := []string{}
+	for _, fieldError := range fieldErrors {
+		fields = append(fields, fieldError.Field)
+	}
+	return &APIError{Kind: KindValidation, Code: ErrorCodeInvalidParameter, Message: "Invalid " + strings.Join(fields, ", "), Fields: fieldErrors}
+}
+
+// Validate - nil if values pass rules, else a 400 error with the first failure of each field.
+// Missing fields are only checked by Required.
+func (rules Rules) Validate(values map[string]string) error {
+	names := []string{}
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fieldErrors := []FieldError{}
+	for _, name := range names {
+		value, ok := values[name]
+		if !ok {
+			if isRequired(rules[name]) {
+				fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "is required"})
+			}
+			continue
+		}
+
+		for _, rule := range rules[name] {
+			if message := rule.check(value, values); message != "" {
+				fieldErrors = append(fieldErrors, FieldError{Field: name, Message: message})
+				break
+			}
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return NewFieldValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// DecodeBody - Unmarshal the JSON object body into v, then Validate its top level fields.
+// Fields are named by their JSON keys, null fields are missing.
+func (rules Rules) DecodeBody(body string, v interface{}) error {
+	fields := map[string]interface{}{}
+	err := json.Unmarshal([]byte(body), &fields)
+	if err == nil {
+		err = json.Unmarshal([]byte(body), v)
+	}
+	if err != nil {
+		return NewValidationError(ErrorCodeBadRequest, "Request body must be a JSON object")
+	}
+
+	values := map[string]string{}
+	for name, field := range fields {
+		switch value := field.(type) {
+		case string:
+			values[name] = value
+		case float64:
+			values[name] = strconv.FormatFloat(value, 'f', -1, 64)
+		case bool:
+			values[name] = strconv.FormatBool(value)
+		case nil:
+		default:
+			// Objects and arrays are checked as their JSON
+			data, _ := json.Marshal(value)
+			values[name] = string(data)
+		}
+	}
+	return rules.Validate(values)
+}
+
+// IntParam - Integer parameter name of params, or def if it is missing. Validate the parameter first.
+func IntParam(params map[string]string, name string, def int64) int64 {
+	if value, ok := params[name]; ok {
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	}
+	return def
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRulesValidate(t *testing.T) {
+	rules := Rules{
+		"abbr":     {Required(), MaxLength(3)},
+		"limit":    {IntRange(1, 200)},
+		"lat":      {FloatRange(-90, 90), RequiredWith("long")},
+		"long":     {FloatRange(-180, 180), RequiredWith("lat"), ExcludedWith("zone")},
+		"sort":     {OneOf("votes")},
+		"token":    {Rejected("must be in a header")},
+		"optional": {MaxLength(1)},
+		"names":    {ListLength(1, 2)},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		fields []FieldError
+	}{
+		{"valid", map[string]string{"abbr": "SG", "limit": "10", "lat": "1.3", "long": "103.8", "sort": "votes"}, nil},
+		{"missing required", map[string]string{}, []FieldError{{Field: "abbr", Message: "is required"}}},
+		{"blank required", map[string]string{"abbr": "  "}, []FieldError{{Field: "abbr", Message: "is required"}}},
+		{"too long", map[string]string{"abbr": "SGPR"}, []FieldError{{Field: "abbr", Message: "must be at most 3 characters"}}},
+		{"int out of range", map[string]string{"abbr": "SG", "limit": "201"}, []FieldError{{Field: "limit", Message: "must be an integer from 1 to 200"}}},
+		{"not an int", map[string]string{"abbr": "SG", "limit": "ten"}, []FieldError{{Field: "limit", Message: "must be an integer from 1 to 200"}}},
+		{"float out of range", map[string]string{"abbr": "SG", "lat": "91", "long": "0"}, []FieldError{{Field: "lat", Message: "must be a number from -90 to 90"}}},
+		{"required with", map[string]string{"abbr": "SG", "lat": "1"}, []FieldError{{Field: "lat", Message: "must be given together with long"}}},
+		{"excluded with", map[string]string{"abbr": "SG", "lat": "1", "long": "1", "zone": "Central"}, []FieldError{{Field: "long", Message: "can't be combined with zone"}}},
+		{"one of", map[string]string{"abbr": "SG", "sort": "name"}, []FieldError{{Field: "sort", Message: "must be one of: votes"}}},
+		{"list", map[string]string{"abbr": "SG", "names": "Singapore, Malaysia,"}, nil},
+		{"empty list", map[string]string{"abbr": "SG", "names": " , "}, []FieldError{{Field: "names", Message: "must have from 1 to 2 comma separated values"}}},
+		{"list too long", map[string]string{"abbr": "SG", "names": "A,B,C"}, []FieldError{{Field: "names", Message: "must have from 1 to 2 comma separated values"}}},
+		{"rejected", map[string]string{"abbr": "SG", "token": "secret"}, []FieldError{{Field: "token", Message: "must be in a header"}}},
+		{"fields sorted, first failure each", map[string]string{"limit": "0", "abbr": "SGPR"}, []FieldError{
+			{Field: "abbr", Message: "must be at most 3 characters"},
+			{Field: "limit", Message: "must be an integer from 1 to 200"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := rules.Validate(test.values)
+			if test.fields == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+
+			apiErr := &APIError{}
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Validate() = %v, want *APIError", err)
+			}
+			if apiErr.Kind != KindValidation || apiErr.Code != ErrorCodeInvalidParameter {
+				t.Errorf("Validate() kind, code = %v, %v, want %v, %v", apiErr.Kind, apiErr.Code, KindValidation, ErrorCodeInvalidParameter)
+			}
+			if !reflect.DeepEqual(apiErr.Fields, test.fields) {
+				t.Errorf("Validate() fields = %v, want %v", apiErr.Fields, test.fields)
+			}
+		})
+	}
+}
+
+func TestRulesDecodeBody(t *testing.T) {
+	type body struct {
+		PlaceID string `json:"place_id"`
+		Count   int64  `json:"count"`
+	}
+	rules := Rules{"place_id": {Required()}, "count": {IntRange(1, 5)}}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    body
+		code    ErrorCode
+		wantErr bool
+	}{
+		{"valid", `{"place_id":"sg-1","count":2}`, body{PlaceID: "sg-1", Count: 2}, "", false},
+		{"null is missing", `{"place_id":null}`, body{}, ErrorCodeInvalidParameter, true},
+		{"number checked as string", `{"place_id":"sg-1","count":9}`, body{PlaceID: "sg-1", Count: 9}, ErrorCodeInvalidParameter, true},
+		{"not an object", `["sg-1"]`, body{}, ErrorCodeBadRequest, true},
+		{"not JSON", `place_id=sg-1`, body{}, ErrorCodeBadRequest, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := body{}
+			err := rules.DecodeBody(test.body, &got)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("DecodeBody() = %v, want error %v", err, test.wantErr)
+			}
+			if err != nil && AsAPIError(err).Code != test.code {
+				t.Errorf("DecodeBody() code = %v, want %v", AsAPIError(err).Code, test.code)
+			}
+			if err == nil && got != test.want {
+				t.Errorf("DecodeBody() decoded %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIntParam(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   int64
+	}{
+		{"present", map[string]string{"limit": "20"}, 20},
+		{"missing", map[string]string{}, 50},
+		{"not an int", map[string]string{"limit": "x"}, 50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IntParam(test.params, "limit", 50); got != test.want {
+				t.Errorf("IntParam() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}